@@ -0,0 +1,126 @@
+package restful
+
+// Copyright 2026 agent. All rights reserved.
+// Use of this source code is governed by a license
+// that can be found in the LICENSE file.
+
+import (
+	"bytes"
+	"net"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/emicklei/go-restful/log"
+)
+
+// AccessLogging is used to create a Filter that logs each request after it has been handled,
+// using the Common Log Format (also known as the NCSA standard) optionally followed by the request headers.
+// Values of headers that are redacted (see RedactHeaders) are logged as "***".
+type AccessLogging struct {
+	Logger          log.StdLogger // if nil then the package logger is used
+	LogHeaders      bool          // if true then the request headers are appended to each log line
+	RedactedHeaders []string      // list of Header names whose values must not appear in the log
+}
+
+// RedactHeaders adds Header names (e.g. Authorization, Cookie) whose values must not appear in the log.
+func (a *AccessLogging) RedactHeaders(names ...string) *AccessLogging {
+	a.RedactedHeaders = append(a.RedactedHeaders, names...)
+	return a
+}
+
+// Filter is a filter function that logs the request after passing it to the next filter or RouteFunction.
+func (a AccessLogging) Filter(req *Request, resp *Response, chain *FilterChain) {
+	chain.ProcessFilter(req, resp)
+	username := "-"
+	if req.Request.URL.User != nil {
+		if name := req.Request.URL.User.Username(); name != "" {
+			username = name
+		}
+	}
+	var buffer bytes.Buffer
+	buffer.WriteString(remoteHost(req.Request.RemoteAddr))
+	buffer.WriteString(" - ")
+	buffer.WriteString(username)
+	buffer.WriteString(" [")
+	buffer.WriteString(time.Now().Format("02/Jan/2006:15:04:05 -0700"))
+	buffer.WriteString("] \"")
+	buffer.WriteString(req.Request.Method + " " + req.Request.URL.RequestURI() + " " + req.Request.Proto)
+	buffer.WriteString("\" ")
+	buffer.WriteString(strconv.Itoa(resp.StatusCode()) + " " + strconv.Itoa(resp.ContentLength()))
+	if a.LogHeaders {
+		buffer.WriteString(" ")
+		buffer.WriteString(formatHeaders(redactHeaders(req.Request.Header, a.RedactedHeaders)))
+	}
+	logger := a.Logger
+	if logger == nil {
+		logger = log.Logger
+	}
+	logger.Print(buffer.String())
+}
+
+// NewSlowRequestFilter returns a filter that logs the method, path and duration of requests
+// that take longer than the threshold to pass through the rest of the chain. Faster requests are not logged.
+// If logger is nil then the package logger is used.
+func NewSlowRequestFilter(threshold time.Duration, logger log.StdLogger) FilterFunction {
+	return func(req *Request, resp *Response, chain *FilterChain) {
+		start := time.Now()
+		chain.ProcessFilter(req, resp)
+		elapsed := time.Since(start)
+		if elapsed <= threshold {
+			return
+		}
+		out := logger
+		if out == nil {
+			out = log.Logger
+		}
+		out.Printf("slow request: %s %s took %v (threshold %v)", req.Request.Method, req.Request.URL.Path, elapsed, threshold)
+	}
+}
+
+// redactHeaders returns a copy of the header in which the values of the named headers are replaced by "***".
+func redactHeaders(header http.Header, names []string) http.Header {
+	redacted := http.Header{}
+	for k, v := range header {
+		redacted[k] = v
+	}
+	for _, each := range names {
+		key := http.CanonicalHeaderKey(each)
+		if _, ok := redacted[key]; ok {
+			redacted[key] = []string{"***"}
+		}
+	}
+	return redacted
+}
+
+// formatHeaders returns the headers, sorted by name, in the format {Name: value,value; Other: value}
+func formatHeaders(header http.Header) string {
+	names := []string{}
+	for k := range header {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	var buffer bytes.Buffer
+	buffer.WriteString("{")
+	for i, each := range names {
+		if i > 0 {
+			buffer.WriteString("; ")
+		}
+		buffer.WriteString(each)
+		buffer.WriteString(": ")
+		buffer.WriteString(strings.Join(header[each], ","))
+	}
+	buffer.WriteString("}")
+	return buffer.String()
+}
+
+// remoteHost returns the host of the remote address, without the port (if any).
+func remoteHost(remoteAddr string) string {
+	host, _, err := net.SplitHostPort(remoteAddr)
+	if err != nil {
+		return remoteAddr
+	}
+	return host
+}
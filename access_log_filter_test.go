@@ -0,0 +1,88 @@
+package restful
+
+import (
+	"bytes"
+	"io"
+	stdlog "log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAccessLoggingRedactsHeaders(t *testing.T) {
+	buffer := new(bytes.Buffer)
+	logging := AccessLogging{Logger: stdlog.New(buffer, "", 0), LogHeaders: true}
+	logging.RedactHeaders("authorization", "Cookie")
+
+	c := NewContainer()
+	ws := new(WebService).Path("/logged")
+	ws.Filter(logging.Filter)
+	ws.Route(ws.GET("").To(func(req *Request, resp *Response) { io.WriteString(resp, "hello") }))
+	c.Add(ws)
+
+	httpRequest, _ := http.NewRequest("GET", "/logged?q=1", nil)
+	httpRequest.RemoteAddr = "10.0.0.1:1234"
+	httpRequest.Header.Set("Authorization", "Bearer secret")
+	httpRequest.Header.Set("Cookie", "session=secret")
+	httpRequest.Header.Set("X-Request-Id", "abc")
+	c.dispatch(httptest.NewRecorder(), httpRequest)
+
+	line := buffer.String()
+	if strings.Contains(line, "secret") {
+		t.Errorf("secret logged:%s", line)
+	}
+	for _, want := range []string{`10.0.0.1 - - [`, `"GET /logged?q=1 HTTP/1.1" 200 5`, "Authorization: ***", "Cookie: ***", "X-Request-Id: abc"} {
+		if !strings.Contains(line, want) {
+			t.Errorf("missing %q in %s", want, line)
+		}
+	}
+}
+
+func TestAccessLoggingWithoutHeaders(t *testing.T) {
+	buffer := new(bytes.Buffer)
+	logging := AccessLogging{Logger: stdlog.New(buffer, "", 0)}
+	httpRequest, _ := http.NewRequest("GET", "/logged", nil)
+	httpRequest.Header.Set("Authorization", "Bearer secret")
+	chain := FilterChain{Target: dummy}
+	logging.Filter(NewRequest(httpRequest), NewResponse(httptest.NewRecorder()), &chain)
+	if strings.Contains(buffer.String(), "Authorization") {
+		t.Errorf("unexpected headers:%s", buffer.String())
+	}
+}
+
+func TestAccessLoggingRemoteHost(t *testing.T) {
+	for _, each := range []struct {
+		remoteAddr, host string
+	}{
+		{"10.0.0.1:1234", "10.0.0.1"},
+		{"[2001:db8::1]:8080", "2001:db8::1"},
+		{"[::1]:1234", "::1"},
+		{"10.0.0.1", "10.0.0.1"},
+		{"", ""},
+	} {
+		if got, want := remoteHost(each.remoteAddr), each.host; got != want {
+			t.Errorf("[%s] got %v want %v", each.remoteAddr, got, want)
+		}
+	}
+}
+
+func TestSlowRequestFilter(t *testing.T) {
+	buffer := new(bytes.Buffer)
+	c := NewContainer()
+	ws := new(WebService).Path("/timed")
+	ws.Filter(NewSlowRequestFilter(20*time.Millisecond, stdlog.New(buffer, "", 0)))
+	ws.Route(ws.GET("/fast").To(doNothing))
+	ws.Route(ws.GET("/slow").To(func(req *Request, resp *Response) { time.Sleep(30 * time.Millisecond) }))
+	c.Add(ws)
+
+	c.dispatch(httptest.NewRecorder(), httptest.NewRequest("GET", "/timed/fast", nil))
+	if got := buffer.String(); got != "" {
+		t.Errorf("fast request logged:%s", got)
+	}
+	c.dispatch(httptest.NewRecorder(), httptest.NewRequest("GET", "/timed/slow", nil))
+	if got, want := buffer.String(), "slow request: GET /timed/slow took "; !strings.HasPrefix(got, want) {
+		t.Errorf("got %q want prefix %q", got, want)
+	}
+}
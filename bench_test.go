@@ -41,3 +41,20 @@ func BenchmarkMany(b *testing.B) {
 		}
 	}
 }
+
+// go test -run none -bench EntityWriter ...restful
+func BenchmarkEntityWriter(b *testing.B) {
+	for name, cache := range map[string]*accessorCache{"uncached": nil, "cached": newAccessorCache()} {
+		b.Run(name, func(b *testing.B) {
+			resp := &Response{
+				requestAccept:  "text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8",
+				routeProduces:  []string{MIME_JSON, MIME_XML},
+				routeAccessors: cache}
+			for i := 0; i < b.N; i++ {
+				if _, ok := resp.EntityWriter(); !ok {
+					b.Fatal("no writer")
+				}
+			}
+		})
+	}
+}
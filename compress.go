@@ -10,7 +10,9 @@ import (
 	"errors"
 	"io"
 	"net/http"
+	"strconv"
 	"strings"
+	"sync"
 )
 
 // OBSOLETE : use restful.DefaultContainer.EnableContentEncoding(true) to change this setting.
@@ -21,6 +23,44 @@ type CompressingResponseWriter struct {
 	writer     http.ResponseWriter
 	compressor io.WriteCloser
 	encoding   string
+	decided    bool // whether the Content-Type of the response has been checked
+	skipped    bool // whether the Content-Type of the response is not worth compressing
+}
+
+// compressionSkipTypes holds the media types of responses that are already compressed.
+// An entry ending with "/*" matches all subtypes.
+var compressionSkipTypes = struct {
+	protection *sync.RWMutex
+	mimes      []string
+}{new(sync.RWMutex), []string{"image/*", "video/*", "audio/*", "application/zip", "application/gzip", "application/x-gzip"}}
+
+// SetCompressionSkipTypes replaces the media types of responses that are written without content encoding,
+// even if compression is enabled and requested. An entry ending with "/*" matches all subtypes.
+// The default is: image/*, video/*, audio/*, application/zip, application/gzip, application/x-gzip.
+func SetCompressionSkipTypes(mimes ...string) {
+	compressionSkipTypes.protection.Lock()
+	defer compressionSkipTypes.protection.Unlock()
+	compressionSkipTypes.mimes = mimes
+}
+
+// skipsCompression returns whether a response with this Content-Type should be written uncompressed.
+func skipsCompression(contentType string) bool {
+	mediaType := strings.ToLower(strings.TrimSpace(strings.Split(contentType, ";")[0]))
+	if len(mediaType) == 0 {
+		return false
+	}
+	compressionSkipTypes.protection.RLock()
+	defer compressionSkipTypes.protection.RUnlock()
+	for _, each := range compressionSkipTypes.mimes {
+		if strings.HasSuffix(each, "/*") {
+			if strings.HasPrefix(mediaType, each[:len(each)-1]) {
+				return true
+			}
+		} else if each == mediaType {
+			return true
+		}
+	}
+	return false
 }
 
 // Header is part of http.ResponseWriter interface
@@ -30,30 +70,76 @@ func (c *CompressingResponseWriter) Header() http.Header {
 
 // WriteHeader is part of http.ResponseWriter interface
 func (c *CompressingResponseWriter) WriteHeader(status int) {
+	c.decide()
 	c.writer.WriteHeader(status)
 }
 
 // Write is part of http.ResponseWriter interface
 // It is passed through the compressor
 func (c *CompressingResponseWriter) Write(bytes []byte) (int, error) {
+	c.decide()
+	if c.skipped {
+		return c.writer.Write(bytes)
+	}
 	if c.isCompressorClosed() {
 		return -1, errors.New("Compressing error: tried to write data using closed compressor")
 	}
 	return c.compressor.Write(bytes)
 }
 
+// Unwrap returns the http.ResponseWriter that receives the compressed content, e.g. for a http.ResponseController.
+func (c *CompressingResponseWriter) Unwrap() http.ResponseWriter {
+	return c.writer
+}
+
 // CloseNotify is part of http.CloseNotifier interface
 func (c *CompressingResponseWriter) CloseNotify() <-chan bool {
 	return c.writer.(http.CloseNotifier).CloseNotify()
 }
 
+// decide checks, once, whether the Content-Type of the response is in the skip list.
+// If so then the Content-Encoding header is removed and the content is written as is.
+func (c *CompressingResponseWriter) decide() {
+	if c.decided {
+		return
+	}
+	c.decided = true
+	if !skipsCompression(c.writer.Header().Get(HEADER_ContentType)) {
+		return
+	}
+	c.writer.Header().Del(HEADER_ContentEncoding)
+	c.skip()
+}
+
+// skip makes the content, if any, be written as is and releases the compressor without closing it.
+// It is also used when the connection is hijacked ; closing would write to it.
+func (c *CompressingResponseWriter) skip() {
+	if c.skipped {
+		return
+	}
+	c.decided = true
+	c.skipped = true
+	if !c.isCompressorClosed() {
+		c.releaseCompressor()
+	}
+}
+
 // Close the underlying compressor
 func (c *CompressingResponseWriter) Close() error {
+	if c.skipped {
+		return nil
+	}
 	if c.isCompressorClosed() {
 		return errors.New("Compressing error: tried to close already closed compressor")
 	}
 
 	c.compressor.Close()
+	c.releaseCompressor()
+	return nil
+}
+
+// releaseCompressor returns pooled compressors to their pool.
+func (c *CompressingResponseWriter) releaseCompressor() {
 	if ENCODING_GZIP == c.encoding {
 		currentCompressorProvider.ReleaseGzipWriter(c.compressor.(*gzip.Writer))
 	}
@@ -62,32 +148,151 @@ func (c *CompressingResponseWriter) Close() error {
 	}
 	// gc hint needed?
 	c.compressor = nil
-	return nil
 }
 
 func (c *CompressingResponseWriter) isCompressorClosed() bool {
 	return nil == c.compressor
 }
 
+// ContentEncoderFunction declares the signature of a function that creates a compressing writer for a content encoding.
+type ContentEncoderFunction func(w io.Writer) (io.WriteCloser, error)
+
+// contentEncoders holds the content encodings, in addition to gzip and deflate, that can be used to compress responses.
+var contentEncoders = struct {
+	protection *sync.RWMutex
+	encoders   map[string]ContentEncoderFunction
+}{new(sync.RWMutex), map[string]ContentEncoderFunction{}}
+
+// RegisterContentEncoder adds a content encoding, such as Brotli (br), that can be used to compress responses
+// if the Accept-Encoding of a request prefers it. This package does not provide such encoders itself ; for example:
+//
+//	restful.RegisterContentEncoder("br", func(w io.Writer) (io.WriteCloser, error) { return brotli.NewWriter(w), nil })
+func RegisterContentEncoder(encoding string, encoder ContentEncoderFunction) {
+	contentEncoders.protection.Lock()
+	defer contentEncoders.protection.Unlock()
+	contentEncoders.encoders[encoding] = encoder
+}
+
+// contentEncoderFor returns the registered encoder function for the encoding, if any.
+func contentEncoderFor(encoding string) (ContentEncoderFunction, bool) {
+	contentEncoders.protection.RLock()
+	defer contentEncoders.protection.RUnlock()
+	encoder, ok := contentEncoders.encoders[encoding]
+	return encoder, ok
+}
+
 // WantsCompressedResponse reads the Accept-Encoding header to see if and which encoding is requested.
+// The supported encoding with the highest quality value is used ; if equal then in order of appearance.
 func wantsCompressedResponse(httpRequest *http.Request) (bool, string) {
 	header := httpRequest.Header.Get(HEADER_AcceptEncoding)
-	gi := strings.Index(header, ENCODING_GZIP)
-	zi := strings.Index(header, ENCODING_DEFLATE)
-	// use in order of appearance
-	if gi == -1 {
-		return zi != -1, ENCODING_DEFLATE
-	} else if zi == -1 {
-		return gi != -1, ENCODING_GZIP
-	} else {
-		if gi < zi {
-			return true, ENCODING_GZIP
+	best, bestQuality := "", 0.0
+	for _, each := range strings.Split(header, ",") {
+		parts := strings.Split(each, ";")
+		encoding := strings.ToLower(strings.TrimSpace(parts[0]))
+		if ENCODING_GZIP != encoding && ENCODING_DEFLATE != encoding {
+			if _, ok := contentEncoderFor(encoding); !ok {
+				continue
+			}
+		}
+		quality := 1.0
+		for _, param := range parts[1:] {
+			if param = strings.TrimSpace(param); strings.HasPrefix(param, "q=") {
+				if q, err := strconv.ParseFloat(param[2:], 64); err == nil {
+					quality = q
+				}
+			}
+		}
+		if quality > bestQuality {
+			best, bestQuality = encoding, quality
+		}
+	}
+	return len(best) > 0, best
+}
+
+// requestContentCodings returns the content codings, in lower case and without identity, listed by the
+// Content-Encoding header(s) of a request, in the order in which they were applied.
+func requestContentCodings(header http.Header) []string {
+	codings := []string{}
+	for _, each := range header.Values(HEADER_ContentEncoding) {
+		for _, coding := range strings.Split(each, ",") {
+			coding = strings.ToLower(strings.TrimSpace(coding))
+			if len(coding) > 0 && "identity" != coding {
+				codings = append(codings, coding)
+			}
+		}
+	}
+	return codings
+}
+
+// isSupportedContentEncoding returns whether all (request) content codings can be decompressed.
+func isSupportedContentEncoding(codings []string) bool {
+	for _, each := range codings {
+		if ENCODING_GZIP != each && ENCODING_DEFLATE != each {
+			return false
+		}
+	}
+	return true
+}
+
+// hasNoBody returns whether the request is known to have no content, such that its Content-Encoding is irrelevant.
+func hasNoBody(httpRequest *http.Request) bool {
+	return httpRequest.ContentLength == 0 && (httpRequest.Body == nil || httpRequest.Body == http.NoBody)
+}
+
+// decompressingReadCloser is a io.ReadCloser that decompresses the request body (gzip or deflate).
+// The decompressor is created on the first Read such that invalid content is detected at read time.
+type decompressingReadCloser struct {
+	original   io.ReadCloser
+	encoding   string
+	gzipReader *gzip.Reader
+	reader     io.ReadCloser
+}
+
+// newDecompressingReadCloser create a decompressingReadCloser for a known encoding = {gzip,deflate}
+func newDecompressingReadCloser(body io.ReadCloser, encoding string) *decompressingReadCloser {
+	return &decompressingReadCloser{original: body, encoding: encoding}
+}
+
+// Read is part of the io.Reader interface.
+// Errors from decompressing are returned as a ServiceError with http.StatusBadRequest.
+func (d *decompressingReadCloser) Read(p []byte) (int, error) {
+	if d.reader == nil {
+		var err error
+		if ENCODING_GZIP == d.encoding {
+			d.gzipReader = currentCompressorProvider.AcquireGzipReader()
+			err = d.gzipReader.Reset(d.original)
+			d.reader = d.gzipReader
+		} else {
+			d.reader, err = zlib.NewReader(d.original)
+		}
+		if err != nil {
+			return 0, d.invalidContent(err)
 		}
-		return true, ENCODING_DEFLATE
 	}
+	n, err := d.reader.Read(p)
+	return n, d.invalidContent(err)
 }
 
-// NewCompressingResponseWriter create a CompressingResponseWriter for a known encoding = {gzip,deflate}
+// invalidContent wraps the error unless it is nil or signals the end of the content.
+func (d *decompressingReadCloser) invalidContent(err error) error {
+	if err == nil || err == io.EOF {
+		return err
+	}
+	return NewError(http.StatusBadRequest, "400: Invalid "+d.encoding+" content: "+err.Error())
+}
+
+// Close is part of the io.Closer interface. It closes the original request body.
+func (d *decompressingReadCloser) Close() error {
+	if d.gzipReader != nil {
+		currentCompressorProvider.ReleaseGzipReader(d.gzipReader)
+		d.gzipReader = nil
+	} else if d.reader != nil {
+		d.reader.Close()
+	}
+	return d.original.Close()
+}
+
+// NewCompressingResponseWriter create a CompressingResponseWriter for a known encoding = {gzip,deflate} or one that is registered
 func NewCompressingResponseWriter(httpWriter http.ResponseWriter, encoding string) (*CompressingResponseWriter, error) {
 	httpWriter.Header().Set(HEADER_ContentEncoding, encoding)
 	c := new(CompressingResponseWriter)
@@ -103,6 +308,12 @@ func NewCompressingResponseWriter(httpWriter http.ResponseWriter, encoding strin
 		w.Reset(httpWriter)
 		c.compressor = w
 		c.encoding = ENCODING_DEFLATE
+	} else if encoder, ok := contentEncoderFor(encoding); ok {
+		c.compressor, err = encoder(httpWriter)
+		if err != nil {
+			return nil, err
+		}
+		c.encoding = encoding
 	} else {
 		return nil, errors.New("Unknown encoding:" + encoding)
 	}
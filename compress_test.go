@@ -8,6 +8,7 @@ import (
 	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 )
 
@@ -80,6 +81,62 @@ func TestDeflate(t *testing.T) {
 	}
 }
 
+func TestSyncPoolCompessorsLevel(t *testing.T) {
+	defer SetCompressorProvider(CurrentCompressorProvider())
+	payload := strings.Repeat("Hello World ", 100)
+	type leveled struct {
+		level    int
+		provider CompressorProvider
+	}
+	providers := []leveled{}
+	for _, level := range []int{gzip.BestSpeed, gzip.DefaultCompression, gzip.BestCompression} {
+		pool, err := NewSyncPoolCompessorsLevel(level)
+		if err != nil {
+			t.Fatal(err)
+		}
+		bounded, err := NewBoundedCachedCompressorsLevel(1, 1, level)
+		if err != nil {
+			t.Fatal(err)
+		}
+		providers = append(providers, leveled{level, pool}, leveled{level, bounded})
+	}
+	for _, each := range providers {
+		level := each.level
+		SetCompressorProvider(each.provider)
+		for _, encoding := range []string{ENCODING_GZIP, ENCODING_DEFLATE} {
+			httpWriter := httptest.NewRecorder()
+			c, err := NewCompressingResponseWriter(httpWriter, encoding)
+			if err != nil {
+				t.Fatal(err)
+			}
+			io.WriteString(c, payload)
+			c.Close()
+			var reader io.Reader
+			if encoding == ENCODING_GZIP {
+				reader, err = gzip.NewReader(httpWriter.Body)
+			} else {
+				reader, err = zlib.NewReader(httpWriter.Body)
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			data, err := ioutil.ReadAll(reader)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(data) != payload {
+				t.Errorf("level %d %s: decompressed output differs", level, encoding)
+			}
+		}
+	}
+	if _, err := NewSyncPoolCompessorsLevel(42); err == nil {
+		t.Error("expected error for invalid level")
+	}
+	if _, err := NewBoundedCachedCompressorsLevel(1, 1, 42); err == nil {
+		t.Error("expected error for invalid level")
+	}
+}
+
 func TestGzipDecompressRequestBody(t *testing.T) {
 	b := new(bytes.Buffer)
 	w := newGzipWriter()
@@ -125,3 +182,254 @@ func TestZlibDecompressRequestBody(t *testing.T) {
 		t.Errorf("got %v want %v", got, want)
 	}
 }
+
+func newGzipEchoContainer() *Container {
+	c := NewContainer()
+	ws := new(WebService).Path("/echo").Consumes(MIME_JSON).Produces(MIME_JSON)
+	ws.Route(ws.POST("").To(func(req *Request, resp *Response) {
+		doc := make(map[string]interface{})
+		if err := req.ReadEntity(&doc); err != nil {
+			if ser, ok := err.(ServiceError); ok {
+				resp.WriteErrorString(ser.Code, ser.Message)
+				return
+			}
+			resp.WriteError(http.StatusInternalServerError, err)
+			return
+		}
+		resp.WriteEntity(doc)
+	}))
+	c.Add(ws)
+	return c
+}
+
+func TestGzipDecompressRequestBodyDispatched(t *testing.T) {
+	for _, doCache := range []bool{true, false} {
+		SetCacheReadEntity(doCache)
+		b := new(bytes.Buffer)
+		w := gzip.NewWriter(b)
+		io.WriteString(w, `{"msg":"hi"}`)
+		w.Close()
+
+		httpRequest, _ := http.NewRequest("POST", "/echo", bytes.NewReader(b.Bytes()))
+		httpRequest.Header.Set("Content-Type", "application/json")
+		httpRequest.Header.Set("Content-Encoding", "gzip")
+		httpWriter := httptest.NewRecorder()
+		newGzipEchoContainer().dispatch(httpWriter, httpRequest)
+
+		if got, want := httpWriter.Code, http.StatusOK; got != want {
+			t.Fatalf("[cache:%v] got %v want %v:%s", doCache, got, want, httpWriter.Body.String())
+		}
+		if got, want := httpWriter.Body.String(), "\"msg\": \"hi\""; !strings.Contains(got, want) {
+			t.Errorf("[cache:%v] got %v want %v", doCache, got, want)
+		}
+	}
+	SetCacheReadEntity(true)
+}
+
+func TestInvalidGzipRequestBody(t *testing.T) {
+	httpRequest, _ := http.NewRequest("POST", "/echo", strings.NewReader("not gzipped"))
+	httpRequest.Header.Set("Content-Type", "application/json")
+	httpRequest.Header.Set("Content-Encoding", "gzip")
+	httpWriter := httptest.NewRecorder()
+	newGzipEchoContainer().dispatch(httpWriter, httpRequest)
+	if got, want := httpWriter.Code, http.StatusBadRequest; got != want {
+		t.Errorf("got %v want %v", got, want)
+	}
+}
+
+func TestUnsupportedRequestContentEncoding(t *testing.T) {
+	httpRequest, _ := http.NewRequest("POST", "/echo", strings.NewReader("?"))
+	httpRequest.Header.Set("Content-Type", "application/json")
+	httpRequest.Header.Set("Content-Encoding", "compress")
+	httpWriter := httptest.NewRecorder()
+	newGzipEchoContainer().dispatch(httpWriter, httpRequest)
+	if got, want := httpWriter.Code, http.StatusUnsupportedMediaType; got != want {
+		t.Errorf("got %v want %v", got, want)
+	}
+}
+
+func TestRequestContentEncodingList(t *testing.T) {
+	deflated := new(bytes.Buffer)
+	z := zlib.NewWriter(deflated)
+	io.WriteString(z, `{"msg":"hi"}`)
+	z.Close()
+	both := new(bytes.Buffer)
+	g := gzip.NewWriter(both)
+	g.Write(deflated.Bytes())
+	g.Close()
+	for header, content := range map[string][]byte{
+		"DEFLATE":           deflated.Bytes(),
+		"Deflate, identity": deflated.Bytes(),
+		"deflate,gzip":      both.Bytes(),
+	} {
+		httpRequest, _ := http.NewRequest("POST", "/echo", bytes.NewReader(content))
+		httpRequest.Header.Set("Content-Type", "application/json")
+		httpRequest.Header.Set("Content-Encoding", header)
+		httpWriter := httptest.NewRecorder()
+		newGzipEchoContainer().dispatch(httpWriter, httpRequest)
+		if got, want := httpWriter.Code, http.StatusOK; got != want {
+			t.Fatalf("[%s] got %v want %v:%s", header, got, want, httpWriter.Body.String())
+		}
+		if got, want := httpWriter.Body.String(), "\"msg\": \"hi\""; !strings.Contains(got, want) {
+			t.Errorf("[%s] got %v want %v", header, got, want)
+		}
+	}
+	for header, want := range map[string]int{
+		"gzip, compress": http.StatusUnsupportedMediaType,
+		"br":             http.StatusUnsupportedMediaType,
+	} {
+		httpRequest, _ := http.NewRequest("POST", "/echo", strings.NewReader("?"))
+		httpRequest.Header.Set("Content-Type", "application/json")
+		httpRequest.Header.Set("Content-Encoding", header)
+		httpWriter := httptest.NewRecorder()
+		newGzipEchoContainer().dispatch(httpWriter, httpRequest)
+		if got := httpWriter.Code; got != want {
+			t.Errorf("[%s] got %v want %v", header, got, want)
+		}
+	}
+}
+
+func TestUnsupportedContentEncodingWithoutBody(t *testing.T) {
+	c := NewContainer()
+	ws := new(WebService).Path("/files")
+	ws.Route(ws.GET("").To(curlyDummy))
+	c.Add(ws)
+	for _, body := range []io.Reader{nil, http.NoBody} {
+		httpRequest, _ := http.NewRequest("GET", "/files", body)
+		httpRequest.Header.Set("Content-Encoding", "compress")
+		httpWriter := httptest.NewRecorder()
+		c.dispatch(httpWriter, httpRequest)
+		if got, want := httpWriter.Code, http.StatusOK; got != want {
+			t.Errorf("[%v] got %v want %v", body, got, want)
+		}
+	}
+}
+
+func TestDisableCompressionForRoute(t *testing.T) {
+	c := NewContainer()
+	c.EnableContentEncoding(true)
+	ws := new(WebService).Path("/files")
+	ws.Route(ws.GET("/plain").To(curlyDummy).DisableCompression())
+	ws.Route(ws.GET("/compressed").To(curlyDummy))
+	c.Add(ws)
+
+	for path, want := range map[string]string{"/files/plain": "", "/files/compressed": "gzip"} {
+		httpRequest, _ := http.NewRequest("GET", path, nil)
+		httpRequest.Header.Set("Accept-Encoding", "gzip")
+		httpWriter := httptest.NewRecorder()
+		c.dispatch(httpWriter, httpRequest)
+		if got := httpWriter.Header().Get("Content-Encoding"); got != want {
+			t.Errorf("[%s] got encoding %q want %q", path, got, want)
+		}
+		if want == "" && httpWriter.Body.String() != "curlyDummy" {
+			t.Errorf("[%s] unexpected body %q", path, httpWriter.Body.String())
+		}
+	}
+}
+
+// nopEncoder is a content encoder for testing that writes its input unchanged.
+type nopEncoder struct{ io.Writer }
+
+func (nopEncoder) Close() error { return nil }
+
+// go test -v -test.run TestRegisteredContentEncoder ...restful
+func TestRegisteredContentEncoder(t *testing.T) {
+	for _, each := range []struct {
+		acceptEncoding, registered, want string
+	}{
+		{"br, gzip", "", ENCODING_GZIP},
+		{"br, gzip", "br", "br"},
+		{"gzip;q=0.5, br", "br", "br"},
+		{"br;q=0.1, gzip", "br", ENCODING_GZIP},
+		{"deflate, gzip", "br", ENCODING_DEFLATE},
+		{"gzip;q=0, deflate;q=0.2", "", ENCODING_DEFLATE},
+	} {
+		if len(each.registered) > 0 {
+			RegisterContentEncoder(each.registered, func(w io.Writer) (io.WriteCloser, error) { return nopEncoder{w}, nil })
+		}
+		httpRequest, _ := http.NewRequest("GET", "/test", nil)
+		httpRequest.Header.Set("Accept-Encoding", each.acceptEncoding)
+		wanted, encoding := wantsCompressedResponse(httpRequest)
+		if !wanted || encoding != each.want {
+			t.Errorf("[%s] got %v,%v want %v", each.acceptEncoding, wanted, encoding, each.want)
+		}
+		if encoding == "br" {
+			httpWriter := httptest.NewRecorder()
+			c, err := NewCompressingResponseWriter(httpWriter, encoding)
+			if err != nil {
+				t.Fatal(err)
+			}
+			c.Write([]byte("Hello World"))
+			c.Close()
+			if got, want := httpWriter.Header().Get("Content-Encoding"), "br"; got != want {
+				t.Errorf("got %v want %v", got, want)
+			}
+			if got, want := httpWriter.Body.String(), "Hello World"; got != want {
+				t.Errorf("got %v want %v", got, want)
+			}
+		}
+		delete(contentEncoders.encoders, "br")
+	}
+}
+
+// go test -v -test.run TestCompressionSkipTypes ...restful
+func TestCompressionSkipTypes(t *testing.T) {
+	c := NewContainer()
+	c.EnableContentEncoding(true)
+	ws := new(WebService).Path("/media")
+	ws.Route(ws.GET("/sample").Produces(MIME_JSON).To(func(req *Request, resp *Response) {
+		resp.WriteEntity(Sample{Value: "compress me"})
+	}))
+	ws.Route(ws.GET("/picture").To(func(req *Request, resp *Response) {
+		resp.Header().Set(HEADER_ContentType, "image/png")
+		resp.Write([]byte("\x89PNG"))
+	}))
+	c.Add(ws)
+
+	for path, want := range map[string]string{"/media/sample": "gzip", "/media/picture": ""} {
+		httpRequest, _ := http.NewRequest("GET", path, nil)
+		httpRequest.Header.Set("Accept-Encoding", "gzip")
+		httpWriter := httptest.NewRecorder()
+		c.dispatch(httpWriter, httpRequest)
+		if got := httpWriter.Header().Get("Content-Encoding"); got != want {
+			t.Errorf("[%s] got encoding %q want %q", path, got, want)
+		}
+		if want == "" && httpWriter.Body.String() != "\x89PNG" {
+			t.Errorf("[%s] unexpected body %q", path, httpWriter.Body.String())
+		}
+		if want == "gzip" {
+			reader, err := gzip.NewReader(httpWriter.Body)
+			if err != nil {
+				t.Fatal(err)
+			}
+			data, _ := ioutil.ReadAll(reader)
+			if !strings.Contains(string(data), "compress me") {
+				t.Errorf("[%s] unexpected body %q", path, data)
+			}
+		}
+	}
+}
+
+func TestSkipsCompression(t *testing.T) {
+	for _, each := range []struct {
+		contentType string
+		want        bool
+	}{
+		{"image/png", true},
+		{"Video/MP4", true},
+		{"application/zip", true},
+		{"application/gzip; charset=binary", true},
+		{"application/json", false},
+		{"application/zipper", false},
+		{"", false},
+	} {
+		if got := skipsCompression(each.contentType); got != each.want {
+			t.Errorf("[%s] got %v want %v", each.contentType, got, each.want)
+		}
+	}
+	SetCompressionSkipTypes("application/json")
+	defer SetCompressionSkipTypes("image/*", "video/*", "audio/*", "application/zip", "application/gzip", "application/x-gzip")
+	if !skipsCompression(MIME_JSON) || skipsCompression("image/png") {
+		t.Error("replaced skip types not used")
+	}
+}
@@ -7,6 +7,7 @@ package restful
 import (
 	"compress/gzip"
 	"compress/zlib"
+	"io/ioutil"
 )
 
 // BoundedCachedCompressors is a CompressorProvider that uses a cache with a fixed amount
@@ -18,20 +19,37 @@ type BoundedCachedCompressors struct {
 	zlibWriters     chan *zlib.Writer
 	writersCapacity int
 	readersCapacity int
+	level           int
 }
 
 // NewBoundedCachedCompressors returns a new, with filled cache,  BoundedCachedCompressors.
+// Its writers use the compression level gzip.DefaultCompression.
 func NewBoundedCachedCompressors(writersCapacity, readersCapacity int) *BoundedCachedCompressors {
+	return newBoundedCachedCompressors(writersCapacity, readersCapacity, gzip.DefaultCompression)
+}
+
+// NewBoundedCachedCompressorsLevel is like NewBoundedCachedCompressors but its gzip and zlib writers use the
+// compression level, from gzip.HuffmanOnly up to gzip.BestCompression, or gzip.DefaultCompression.
+// An error is returned for an invalid level.
+func NewBoundedCachedCompressorsLevel(writersCapacity, readersCapacity, level int) (*BoundedCachedCompressors, error) {
+	if _, err := gzip.NewWriterLevel(ioutil.Discard, level); err != nil {
+		return nil, err
+	}
+	return newBoundedCachedCompressors(writersCapacity, readersCapacity, level), nil
+}
+
+func newBoundedCachedCompressors(writersCapacity, readersCapacity, level int) *BoundedCachedCompressors {
 	b := &BoundedCachedCompressors{
 		gzipWriters:     make(chan *gzip.Writer, writersCapacity),
 		gzipReaders:     make(chan *gzip.Reader, readersCapacity),
 		zlibWriters:     make(chan *zlib.Writer, writersCapacity),
 		writersCapacity: writersCapacity,
 		readersCapacity: readersCapacity,
+		level:           level,
 	}
 	for ix := 0; ix < writersCapacity; ix++ {
-		b.gzipWriters <- newGzipWriter()
-		b.zlibWriters <- newZlibWriter()
+		b.gzipWriters <- newGzipWriterLevel(level)
+		b.zlibWriters <- newZlibWriterLevel(level)
 	}
 	for ix := 0; ix < readersCapacity; ix++ {
 		b.gzipReaders <- newGzipReader()
@@ -46,7 +64,7 @@ func (b *BoundedCachedCompressors) AcquireGzipWriter() *gzip.Writer {
 	case writer, _ = <-b.gzipWriters:
 	default:
 		// return a new unmanaged one
-		writer = newGzipWriter()
+		writer = newGzipWriterLevel(b.level)
 	}
 	return writer
 }
@@ -88,7 +106,7 @@ func (b *BoundedCachedCompressors) AcquireZlibWriter() *zlib.Writer {
 	case writer, _ = <-b.zlibWriters:
 	default:
 		// return a new unmanaged one
-		writer = newZlibWriter()
+		writer = newZlibWriterLevel(b.level)
 	}
 	return writer
 }
@@ -8,6 +8,7 @@ import (
 	"bytes"
 	"compress/gzip"
 	"compress/zlib"
+	"io/ioutil"
 	"sync"
 )
 
@@ -19,16 +20,31 @@ type SyncPoolCompessors struct {
 }
 
 // NewSyncPoolCompessors returns a new ("empty") SyncPoolCompessors.
+// Its writers use the compression level gzip.DefaultCompression.
 func NewSyncPoolCompessors() *SyncPoolCompessors {
+	return newSyncPoolCompessors(gzip.DefaultCompression)
+}
+
+// NewSyncPoolCompessorsLevel returns a new ("empty") SyncPoolCompessors whose gzip and zlib writers
+// use the compression level, from gzip.HuffmanOnly up to gzip.BestCompression, or gzip.DefaultCompression.
+// Use it with SetCompressorProvider to trade CPU for response size. An error is returned for an invalid level.
+func NewSyncPoolCompessorsLevel(level int) (*SyncPoolCompessors, error) {
+	if _, err := gzip.NewWriterLevel(ioutil.Discard, level); err != nil {
+		return nil, err
+	}
+	return newSyncPoolCompessors(level), nil
+}
+
+func newSyncPoolCompessors(level int) *SyncPoolCompessors {
 	return &SyncPoolCompessors{
 		GzipWriterPool: &sync.Pool{
-			New: func() interface{} { return newGzipWriter() },
+			New: func() interface{} { return newGzipWriterLevel(level) },
 		},
 		GzipReaderPool: &sync.Pool{
 			New: func() interface{} { return newGzipReader() },
 		},
 		ZlibWriterPool: &sync.Pool{
-			New: func() interface{} { return newZlibWriter() },
+			New: func() interface{} { return newZlibWriterLevel(level) },
 		},
 	}
 }
@@ -58,8 +74,12 @@ func (s *SyncPoolCompessors) ReleaseZlibWriter(w *zlib.Writer) {
 }
 
 func newGzipWriter() *gzip.Writer {
+	return newGzipWriterLevel(gzip.DefaultCompression)
+}
+
+func newGzipWriterLevel(level int) *gzip.Writer {
 	// create with an empty bytes writer; it will be replaced before using the gzipWriter
-	writer, err := gzip.NewWriterLevel(new(bytes.Buffer), gzip.BestSpeed)
+	writer, err := gzip.NewWriterLevel(new(bytes.Buffer), level)
 	if err != nil {
 		panic(err.Error())
 	}
@@ -83,7 +103,11 @@ func newGzipReader() *gzip.Reader {
 }
 
 func newZlibWriter() *zlib.Writer {
-	writer, err := zlib.NewWriterLevel(new(bytes.Buffer), gzip.BestSpeed)
+	return newZlibWriterLevel(gzip.DefaultCompression)
+}
+
+func newZlibWriterLevel(level int) *zlib.Writer {
+	writer, err := zlib.NewWriterLevel(new(bytes.Buffer), level)
 	if err != nil {
 		panic(err.Error())
 	}
@@ -9,12 +9,26 @@ const (
 	MIME_JSON  = "application/json"         // Accept or Content-Type used in Consumes() and/or Produces()
 	MIME_OCTET = "application/octet-stream" // If Content-Type is not present in request, use the default
 
+	MIME_NDJSON = "application/x-ndjson" // newline delimited JSON, see Request.ReadEntities
+
 	HEADER_Allow                         = "Allow"
 	HEADER_Accept                        = "Accept"
+	HEADER_AcceptPost                    = "Accept-Post"
+	HEADER_AcceptPatch                   = "Accept-Patch"
 	HEADER_Origin                        = "Origin"
 	HEADER_ContentType                   = "Content-Type"
+	HEADER_ContentDisposition            = "Content-Disposition"
 	HEADER_LastModified                  = "Last-Modified"
+	HEADER_ETag                          = "ETag"
+	HEADER_IfMatch                       = "If-Match"
+	HEADER_IdempotencyKey                = "Idempotency-Key"
+	HEADER_ServerTiming                  = "Server-Timing"
+	HEADER_RequestDeadline               = "X-Request-Deadline"
+	HEADER_Location                      = "Location"
+	HEADER_Vary                          = "Vary"
+	HEADER_GrpcTimeout                   = "Grpc-Timeout"
 	HEADER_AcceptEncoding                = "Accept-Encoding"
+	HEADER_AcceptLanguage                = "Accept-Language"
 	HEADER_ContentEncoding               = "Content-Encoding"
 	HEADER_AccessControlExposeHeaders    = "Access-Control-Expose-Headers"
 	HEADER_AccessControlRequestMethod    = "Access-Control-Request-Method"
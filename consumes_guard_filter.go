@@ -0,0 +1,33 @@
+package restful
+
+// Copyright 2026 agent. All rights reserved.
+// Use of this source code is governed by a license
+// that can be found in the LICENSE file.
+
+import (
+	"net/http"
+	"strings"
+)
+
+// NewConsumesGuardFilter returns a filter that checks the Content-Type of the request against what the selected Route consumes.
+// If it does not match then Http Status UnsupportedMediaType (415) is written, with the consumed MIME types
+// listed in the Accept-Patch (for PATCH), Accept-Post (for POST) or else the Accept header of the response.
+// Use it if the Route was selected without checking the Content-Type, e.g. by a custom RouteSelector.
+func NewConsumesGuardFilter() FilterFunction {
+	return func(req *Request, resp *Response, chain *FilterChain) {
+		route := req.selectedRoute
+		if route != nil && !route.matchesContentType(req.Request.Header.Get(HEADER_ContentType)) {
+			header := HEADER_Accept
+			switch req.Request.Method {
+			case "PATCH":
+				header = HEADER_AcceptPatch
+			case "POST":
+				header = HEADER_AcceptPost
+			}
+			resp.Header().Set(header, strings.Join(route.Consumes, ", "))
+			resp.WriteErrorString(http.StatusUnsupportedMediaType, "415: Unsupported Media Type")
+			return
+		}
+		chain.ProcessFilter(req, resp)
+	}
+}
@@ -0,0 +1,44 @@
+package restful
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestConsumesGuardFilter(t *testing.T) {
+	ws := new(WebService).Path("/orders").Consumes(MIME_JSON, MIME_XML)
+	ws.Route(ws.POST("").To(doNothing))
+	ws.Route(ws.PATCH("/{id}").To(doNothing))
+	for _, each := range []struct {
+		method, contentType string
+		code                int
+		header              string
+	}{
+		{"POST", MIME_JSON, http.StatusOK, ""},
+		{"POST", "application/xml; charset=utf-8", http.StatusOK, ""},
+		{"POST", "text/csv", http.StatusUnsupportedMediaType, HEADER_AcceptPost},
+		{"PATCH", "text/csv", http.StatusUnsupportedMediaType, HEADER_AcceptPatch},
+	} {
+		route := ws.Routes()[0]
+		if each.method == "PATCH" {
+			route = ws.Routes()[1]
+		}
+		httpRequest, _ := http.NewRequest(each.method, "/orders", strings.NewReader("{}"))
+		httpRequest.Header.Set(HEADER_ContentType, each.contentType)
+		req := NewRequest(httpRequest)
+		req.selectedRoute = &route
+		httpWriter := httptest.NewRecorder()
+		chain := FilterChain{Filters: []FilterFunction{NewConsumesGuardFilter()}, Target: doNothing}
+		chain.ProcessFilter(req, NewResponse(httpWriter))
+		if got, want := httpWriter.Code, each.code; got != want {
+			t.Errorf("[%s %s] got %v want %v", each.method, each.contentType, got, want)
+		}
+		if len(each.header) > 0 {
+			if got, want := httpWriter.Header().Get(each.header), "application/json, application/xml"; got != want {
+				t.Errorf("[%s %s] got %v want %v", each.method, each.contentType, got, want)
+			}
+		}
+	}
+}
@@ -6,6 +6,7 @@ package restful
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"net/http"
 	"os"
@@ -26,9 +27,11 @@ type Container struct {
 	containerFilters       []FilterFunction
 	doNotRecover           bool // default is false
 	recoverHandleFunc      RecoverHandleFunction
+	recoverHandlerSet      bool // true if RecoverHandler was called
 	serviceErrorHandleFunc ServiceErrorHandleFunction
 	router                 RouteSelector // default is a RouterJSR311, CurlyRouter is the faster alternative
 	contentEncodingEnabled bool          // default is false
+	inFlight               *requestTracker
 }
 
 // NewContainer creates a new Container using a new ServeMux and default router (RouterJSR311)
@@ -42,7 +45,8 @@ func NewContainer() *Container {
 		recoverHandleFunc:      logStackOnRecover,
 		serviceErrorHandleFunc: writeServiceError,
 		router:                 RouterJSR311{},
-		contentEncodingEnabled: false}
+		contentEncodingEnabled: false,
+		inFlight:               new(requestTracker)}
 }
 
 // RecoverHandleFunction declares functions that can be used to handle a panic situation.
@@ -53,6 +57,7 @@ type RecoverHandleFunction func(interface{}, http.ResponseWriter)
 // when a panic is detected. DoNotRecover must be have its default value (=false).
 func (c *Container) RecoverHandler(handler RecoverHandleFunction) {
 	c.recoverHandleFunc = handler
+	c.recoverHandlerSet = true
 }
 
 // ServiceErrorHandleFunction declares functions that can be used to handle a service error situation.
@@ -84,9 +89,15 @@ func (c *Container) EnableContentEncoding(enabled bool) {
 }
 
 // Add a WebService to the Container. It will detect duplicate root paths and panic in that case.
+// A WebService with an invalid root path, see SetPanicOnInvalidPath, is not added and its *PathError is logged.
 func (c *Container) Add(service *WebService) *Container {
 	c.webServicesLock.Lock()
 	defer c.webServicesLock.Unlock()
+	// a WebService with an invalid root path cannot be dispatched to
+	if service.pathErr != nil {
+		log.Printf("[restful] WebService not added because of %v", service.pathErr)
+		return c
+	}
 	// If registered on root then no additional specific mapping is needed
 	if !c.isRegisteredOnRoot {
 		pattern := c.fixedPrefixPath(service.RootPath())
@@ -142,8 +153,17 @@ func (c *Container) Remove(ws *WebService) error {
 // logStackOnRecover is the default RecoverHandleFunction and is called
 // when DoNotRecover is false and the recoverHandleFunc is not set for the container.
 // Default implementation logs the stacktrace and writes the stacktrace on the response.
+// If the WebService has an error response content type (see SetErrorResponseContentType) then
+// the stacktrace is written as the message of a ServiceError using that type instead.
 // This may be a security issue as it exposes sourcecode information.
 func logStackOnRecover(panicReason interface{}, httpWriter http.ResponseWriter) {
+	stack := recoveredStack(panicReason)
+	httpWriter.WriteHeader(http.StatusInternalServerError)
+	httpWriter.Write([]byte(stack))
+}
+
+// recoveredStack logs and returns the reason of the panic and the stacktrace.
+func recoveredStack(panicReason interface{}) string {
 	var buffer bytes.Buffer
 	buffer.WriteString(fmt.Sprintf("[restful] recover from panic situation: - %v\r\n", panicReason))
 	for i := 2; ; i += 1 {
@@ -154,14 +174,24 @@ func logStackOnRecover(panicReason interface{}, httpWriter http.ResponseWriter)
 		buffer.WriteString(fmt.Sprintf("    %s:%d\r\n", file, line))
 	}
 	log.Print(buffer.String())
-	httpWriter.WriteHeader(http.StatusInternalServerError)
-	httpWriter.Write(buffer.Bytes())
+	return buffer.String()
 }
 
 // writeServiceError is the default ServiceErrorHandleFunction and is called
 // when a ServiceError is returned during route selection. Default implementation
-// calls resp.WriteErrorString(err.Code, err.Message)
+// calls resp.WriteErrorString(err.Code, err.Message) unless the WebService has an
+// error response content type (see SetErrorResponseContentType) which is then used to write the err.
+// If error messages are registered (see RegisterErrorMessages) then the localized message replaces err.Message.
 func writeServiceError(err ServiceError, req *Request, resp *Response) {
+	if msg, ok := errorMessageCatalog.MessageFor(err.Code, resp.requestAcceptLanguage); ok {
+		err.Message = msg
+	}
+	if len(resp.errorContentType) > 0 {
+		resp.err = err
+		if writeErr := resp.WriteAsContentType(err.Code, resp.errorContentType, err); writeErr == nil {
+			return
+		}
+	}
 	resp.WriteErrorString(err.Code, err.Message)
 }
 
@@ -169,6 +199,10 @@ func writeServiceError(err ServiceError, req *Request, resp *Response) {
 func (c *Container) dispatch(httpWriter http.ResponseWriter, httpRequest *http.Request) {
 	writer := httpWriter
 
+	// Track the request until all deferred operations are done
+	c.inFlight.begin()
+	defer c.inFlight.end()
+
 	// CompressingResponseWriter should be closed after all operations are done
 	defer func() {
 		if compressWriter, ok := writer.(*CompressingResponseWriter); ok {
@@ -176,10 +210,20 @@ func (c *Container) dispatch(httpWriter http.ResponseWriter, httpRequest *http.R
 		}
 	}()
 
+	var webService *WebService
 	// Instal panic recovery unless told otherwise
 	if !c.doNotRecover { // catch all for 500 response
 		defer func() {
 			if r := recover(); r != nil {
+				if !c.recoverHandlerSet && webService != nil && len(webService.errorContentType) > 0 {
+					if _, ok := accessorAt(webService.accessors, webService.errorContentType); ok {
+						_, resp := newBasicRequestResponse(writer, httpRequest)
+						resp.serviceAccessors = webService.accessors
+						resp.WriteAsContentType(http.StatusInternalServerError, webService.errorContentType,
+							NewError(http.StatusInternalServerError, recoveredStack(r)))
+						return
+					}
+				}
 				c.recoverHandleFunc(r, writer)
 				return
 			}
@@ -192,9 +236,31 @@ func (c *Container) dispatch(httpWriter http.ResponseWriter, httpRequest *http.R
 		}
 	}()
 
+	// Find best match Route ; err is non nil if no match was found
+	var route *Route
+	var err error
+	func() {
+		c.webServicesLock.RLock()
+		defer c.webServicesLock.RUnlock()
+		webService, route, err = c.router.SelectRoute(
+			c.webServices,
+			httpRequest)
+		if stripped, ws, r, ok, serr := c.selectRouteBySuffix(httpRequest, webService); ok {
+			httpRequest, webService, route, err = stripped, ws, r, serr
+			return
+		}
+		if err != nil {
+			// explain for the WebService that was selected by its root path, or else those whose root path matches
+			for _, each := range c.webServices {
+				if each.explainRouting && (each == webService || webService == nil && each.PathPrefixMatches(httpRequest.URL.Path)) {
+					each.logRoutingExplanation(httpRequest)
+				}
+			}
+		}
+	}()
 	// Detect if compression is needed
 	// assume without compression, test for override
-	if c.contentEncodingEnabled {
+	if c.contentEncodingEnabled && (route == nil || !route.DisableCompression) {
 		doCompress, encoding := wantsCompressedResponse(httpRequest)
 		if doCompress {
 			var err error
@@ -206,17 +272,9 @@ func (c *Container) dispatch(httpWriter http.ResponseWriter, httpRequest *http.R
 			}
 		}
 	}
-	// Find best match Route ; err is non nil if no match was found
-	var webService *WebService
-	var route *Route
-	var err error
-	func() {
-		c.webServicesLock.RLock()
-		defer c.webServicesLock.RUnlock()
-		webService, route, err = c.router.SelectRoute(
-			c.webServices,
-			httpRequest)
-	}()
+	if err == nil && !hasNoBody(httpRequest) && !isSupportedContentEncoding(requestContentCodings(httpRequest.Header)) {
+		err = NewError(http.StatusUnsupportedMediaType, "415: Unsupported Content-Encoding")
+	}
 	if err != nil {
 		// a non-200 response has already been written
 		// run container filters anyway ; they should not touch the response...
@@ -224,20 +282,46 @@ func (c *Container) dispatch(httpWriter http.ResponseWriter, httpRequest *http.R
 			switch err.(type) {
 			case ServiceError:
 				ser := err.(ServiceError)
+				if webService != nil {
+					if handler := webService.missHandler(ser.Code); handler != nil {
+						handler(req, resp)
+						return
+					}
+				}
 				c.serviceErrorHandleFunc(ser, req, resp)
 			}
 			// TODO
 		}}
-		chain.ProcessFilter(NewRequest(httpRequest), NewResponse(writer))
+		basicRequest, basicResponse := newBasicRequestResponse(writer, httpRequest)
+		if webService != nil {
+			basicResponse.errorContentType = webService.errorContentType
+			basicResponse.serviceAccessors = webService.accessors
+			webService.setDefaultResponseHeaders(basicResponse)
+		}
+		if webService != nil && webService.requestObserver != nil {
+			webService.requestObserver(true, basicRequest, basicResponse, Route{})
+			defer webService.requestObserver(false, basicRequest, basicResponse, Route{})
+		}
+		chain.ProcessFilter(basicRequest, basicResponse)
 		return
 	}
 	wrappedRequest, wrappedResponse := route.wrapRequestResponse(writer, httpRequest)
+	wrappedResponse.errorContentType = webService.errorContentType
+	wrappedResponse.defaultContentType = webService.defaultResponseContentType
+	wrappedRequest.serviceAccessors = webService.accessors
+	wrappedResponse.serviceAccessors = webService.accessors
+	webService.setDefaultResponseHeaders(wrappedResponse)
+	if webService.requestObserver != nil {
+		webService.requestObserver(true, wrappedRequest, wrappedResponse, *route)
+		defer webService.requestObserver(false, wrappedRequest, wrappedResponse, *route)
+	}
 	// pass through filters (if any)
-	if len(c.containerFilters)+len(webService.filters)+len(route.Filters) > 0 {
+	serviceFilters := webService.dispatchFilters()
+	if len(c.containerFilters)+len(serviceFilters)+len(route.Filters) > 0 {
 		// compose filter chain
 		allFilters := []FilterFunction{}
 		allFilters = append(allFilters, c.containerFilters...)
-		allFilters = append(allFilters, webService.filters...)
+		allFilters = append(allFilters, serviceFilters...)
 		allFilters = append(allFilters, route.Filters...)
 		chain := FilterChain{Filters: allFilters, Target: func(req *Request, resp *Response) {
 			// handle request by route after passing all filters
@@ -250,6 +334,22 @@ func (c *Container) dispatch(httpWriter http.ResponseWriter, httpRequest *http.R
 	}
 }
 
+// Drain blocks until all requests that are being dispatched by the container have finished
+// or the context is done, in which case the context error is returned.
+// Typically called after http.Server.Shutdown has stopped accepting new connections.
+func (c *Container) Drain(ctx context.Context) error {
+	idle := c.inFlight.idle()
+	if idle == nil {
+		return nil
+	}
+	select {
+	case <-idle:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
 // fixedPrefixPath returns the fixed part of the partspec ; it may include template vars {}
 func (c Container) fixedPrefixPath(pathspec string) string {
 	varBegin := strings.Index(pathspec, "{")
@@ -334,5 +434,43 @@ func (c Container) computeAllowedMethods(req *Request) []string {
 func newBasicRequestResponse(httpWriter http.ResponseWriter, httpRequest *http.Request) (*Request, *Response) {
 	resp := NewResponse(httpWriter)
 	resp.requestAccept = httpRequest.Header.Get(HEADER_Accept)
+	resp.requestAcceptLanguage = httpRequest.Header.Get(HEADER_AcceptLanguage)
 	return NewRequest(httpRequest), resp
 }
+
+// requestTracker counts the requests in flight.
+// Unlike a sync.WaitGroup, it can be waited on while new requests keep arriving.
+type requestTracker struct {
+	lock    sync.Mutex
+	active  int
+	drained chan struct{}
+}
+
+func (t *requestTracker) begin() {
+	t.lock.Lock()
+	t.active++
+	t.lock.Unlock()
+}
+
+func (t *requestTracker) end() {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	t.active--
+	if t.active == 0 && t.drained != nil {
+		close(t.drained)
+		t.drained = nil
+	}
+}
+
+// idle returns a channel that is closed when no requests are active, or nil if none are.
+func (t *requestTracker) idle() <-chan struct{} {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	if t.active == 0 {
+		return nil
+	}
+	if t.drained == nil {
+		t.drained = make(chan struct{})
+	}
+	return t.drained
+}
@@ -1,9 +1,12 @@
 package restful
 
 import (
+	"context"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 )
 
 // go test -v -test.run TestContainer_computeAllowedMethods ...restful
@@ -59,3 +62,87 @@ func TestContainer_HandleWithFilter(t *testing.T) {
 		t.Errorf("handler added by calling HandleWithFilter wasn't called")
 	}
 }
+
+func TestContainer_Drain(t *testing.T) {
+	started := make(chan struct{})
+	release := make(chan struct{})
+	wc := NewContainer()
+	ws := new(WebService).Path("/slow")
+	ws.Route(ws.GET("").To(func(req *Request, resp *Response) {
+		close(started)
+		<-release
+	}))
+	wc.Add(ws)
+
+	finished := make(chan struct{})
+	go func() {
+		httpRequest, _ := http.NewRequest("GET", "/slow", nil)
+		wc.dispatch(httptest.NewRecorder(), httpRequest)
+		close(finished)
+	}()
+	<-started
+
+	short, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if got, want := wc.Drain(short), context.DeadlineExceeded; got != want {
+		t.Errorf("got %v want %v", got, want)
+	}
+
+	drained := make(chan error)
+	go func() { drained <- wc.Drain(context.Background()) }()
+	select {
+	case <-drained:
+		t.Fatal("drain returned while a request was in flight")
+	case <-time.After(10 * time.Millisecond):
+	}
+	close(release)
+	if err := <-drained; err != nil {
+		t.Errorf("got %v want nil", err)
+	}
+	<-finished
+	if err := wc.Drain(context.Background()); err != nil {
+		t.Errorf("got %v want nil when idle", err)
+	}
+}
+
+func TestContainer_AddInvalidRootPath(t *testing.T) {
+	SetPanicOnInvalidPath(false)
+	defer SetPanicOnInvalidPath(true)
+	for _, router := range []RouteSelector{RouterJSR311{}, CurlyRouter{}} {
+		wc := NewContainer()
+		wc.Router(router)
+		invalid := new(WebService).Path("/users/{id:[}")
+		invalid.Route(invalid.GET("").To(dummy))
+		wc.Add(invalid)
+		if got, want := len(wc.RegisteredWebServices()), 0; got != want {
+			t.Fatalf("[%T] got %v want %v", router, got, want)
+		}
+		httpRequest, _ := http.NewRequest("GET", "/users/{id:[}", nil)
+		httpWriter := httptest.NewRecorder()
+		wc.dispatch(httpWriter, httpRequest)
+		if got, want := httpWriter.Code, http.StatusNotFound; got != want {
+			t.Errorf("[%T] got %v want %v", router, got, want)
+		}
+	}
+}
+
+func TestContainer_RecoverErrorResponseContentType(t *testing.T) {
+	wc := NewContainer()
+	ws := new(WebService).Path("/panic").SetErrorResponseContentType(MIME_JSON)
+	ws.Route(ws.GET("").To(func(req *Request, resp *Response) {
+		panic("boom")
+	}))
+	wc.Add(ws)
+	httpRequest, _ := http.NewRequest("GET", "/panic", nil)
+	httpWriter := httptest.NewRecorder()
+	wc.dispatch(httpWriter, httpRequest)
+	if got, want := httpWriter.Code, http.StatusInternalServerError; got != want {
+		t.Errorf("got %v want %v", got, want)
+	}
+	if got, want := httpWriter.Header().Get("Content-Type"), MIME_JSON; got != want {
+		t.Errorf("got %v want %v", got, want)
+	}
+	if got, want := httpWriter.Body.String(), `"code": 500`; !strings.Contains(got, want) {
+		t.Errorf("got %v want %v", got, want)
+	}
+}
@@ -20,7 +20,9 @@ func (c CurlyRouter) SelectRoute(
 	webServices []*WebService,
 	httpRequest *http.Request) (selectedService *WebService, selected *Route, err error) {
 
-	requestTokens := tokenizePath(httpRequest.URL.Path)
+	borrowed := borrowPathTokens(httpRequest.URL.Path)
+	defer releasePathTokens(borrowed)
+	requestTokens := *borrowed
 
 	detectedService := c.detectWebService(requestTokens, webServices)
 	if detectedService == nil {
@@ -49,7 +51,7 @@ func (c CurlyRouter) selectRoutes(ws *WebService, requestTokens []string) []Rout
 	for _, each := range ws.routes {
 		matches, paramCount, staticCount := c.matchesRouteByPathTokens(each.pathParts, requestTokens)
 		if matches {
-			candidates.add(&curlyRoute{each, paramCount, staticCount}) // TODO make sure Routes() return pointers?
+			candidates.add(&curlyRoute{each, paramCount, staticCount, c.wildcardCount(each.pathParts)}) // TODO make sure Routes() return pointers?
 		}
 	}
 	sort.Sort(sort.Reverse(candidates))
@@ -68,7 +70,11 @@ func (c CurlyRouter) matchesRouteByPathTokens(routeTokens, requestTokens []strin
 	}
 	for i, routeToken := range routeTokens {
 		if i == len(requestTokens) {
-			// reached end of request path
+			// reached end of request path ; only an optional last parameter may be absent
+			if i == len(routeTokens)-1 && isOptionalParameterToken(routeToken) {
+				paramCount++
+				break
+			}
 			return false, 0, 0
 		}
 		requestToken := requestTokens[i]
@@ -94,6 +100,16 @@ func (c CurlyRouter) matchesRouteByPathTokens(routeTokens, requestTokens []strin
 	return true, paramCount, staticCount
 }
 
+// wildcardCount returns the number of route tokens that match the remainder of a path, e.g. {rest:*}
+func (c CurlyRouter) wildcardCount(routeTokens []string) (count int) {
+	for _, each := range routeTokens {
+		if strings.HasPrefix(each, "{") && strings.HasSuffix(each, ":*}") {
+			count++
+		}
+	}
+	return count
+}
+
 // regularMatchesPathToken tests whether the regular expression part of routeToken matches the requestToken or all remaining tokens
 // format routeToken is {someVar:someExpression}, e.g. {zipcode:[\d][\d][\d][\d][A-Z][A-Z]}
 func (c CurlyRouter) regularMatchesPathToken(routeToken string, colon int, requestToken string) (matchesToken bool, matchesRemainder bool) {
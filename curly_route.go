@@ -5,10 +5,12 @@ package restful
 // that can be found in the LICENSE file.
 
 // curlyRoute exits for sorting Routes by the CurlyRouter based on number of parameters and number of static path elements.
+// The most specific route is sorted first: static elements beat {param} elements which beat {param:*} elements.
 type curlyRoute struct {
-	route       Route
-	paramCount  int
-	staticCount int
+	route         Route
+	paramCount    int
+	staticCount   int
+	wildcardCount int
 }
 
 type sortableCurlyRoutes struct {
@@ -43,7 +45,14 @@ func (s *sortableCurlyRoutes) Less(i, j int) bool {
 	if ci.staticCount > cj.staticCount {
 		return false
 	}
-	// secundary key
+	// secundary key ; a wildcard is less specific
+	if ci.wildcardCount > cj.wildcardCount {
+		return true
+	}
+	if ci.wildcardCount < cj.wildcardCount {
+		return false
+	}
+	// tertiary key
 	if ci.paramCount < cj.paramCount {
 		return true
 	}
@@ -113,6 +113,9 @@ var routeMatchers = []struct {
 	{"/a/{x:*}", "/a/b", true, 1, 1},
 	{"/a/{x:[A-Z][A-Z]}", "/a/ZX", true, 1, 1},
 	{"/basepath/{resource:*}", "/basepath/some/other/location/test.xml", true, 1, 1},
+	{"/items/{category?}", "/items", true, 1, 1},
+	{"/items/{category?}", "/items/books", true, 1, 1},
+	{"/items/{category?}/{id}", "/items", false, 0, 0},
 }
 
 // clear && go test -v -test.run Test_matchesRouteByPathTokens ...restful
@@ -228,4 +231,34 @@ func TestCurly_ISSUE_137_2(t *testing.T) {
 	}
 }
 
+// go test -v -test.run TestCurly_MostSpecificRoute ...restful
+func TestCurly_MostSpecificRoute(t *testing.T) {
+	for _, literalFirst := range []bool{true, false} {
+		ws1 := new(WebService).Path("/users")
+		if literalFirst {
+			ws1.Route(ws1.GET("/me").To(curlyDummy))
+			ws1.Route(ws1.GET("/{id}").To(curlyDummy))
+			ws1.Route(ws1.GET("/{rest:*}").To(curlyDummy))
+		} else {
+			ws1.Route(ws1.GET("/{rest:*}").To(curlyDummy))
+			ws1.Route(ws1.GET("/{id}").To(curlyDummy))
+			ws1.Route(ws1.GET("/me").To(curlyDummy))
+		}
+		for path, want := range map[string]string{
+			"/users/me":   "/users/me",
+			"/users/42":   "/users/{id}",
+			"/users/42/x": "/users/{rest:*}",
+		} {
+			req, _ := http.NewRequest("GET", path, nil)
+			_, route, err := CurlyRouter{}.SelectRoute([]*WebService{ws1}, req)
+			if err != nil {
+				t.Fatalf("[%v] unexpected error %v", literalFirst, err)
+			}
+			if got := route.Path; got != want {
+				t.Errorf("[%v] %s got route %s want %s", literalFirst, path, got, want)
+			}
+		}
+	}
+}
+
 func curlyDummy(req *Request, resp *Response) { io.WriteString(resp.ResponseWriter, "curlyDummy") }
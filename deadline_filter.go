@@ -0,0 +1,105 @@
+package restful
+
+// Copyright 2026 agent. All rights reserved.
+// Use of this source code is governed by a license
+// that can be found in the LICENSE file.
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// grpcTimeoutUnits maps the unit of a grpc-timeout value to its duration.
+var grpcTimeoutUnits = map[byte]time.Duration{
+	'H': time.Hour,
+	'M': time.Minute,
+	'S': time.Second,
+	'm': time.Millisecond,
+	'u': time.Microsecond,
+	'n': time.Nanosecond,
+}
+
+// deadlineHeader is a request header that carries a deadline and the function that parses its value.
+type deadlineHeader struct {
+	name  string
+	parse func(value string, now time.Time) (time.Time, error)
+}
+
+// NewDeadlineFilter returns a filter that reads the deadline of a request, set by an upstream service,
+// from the header and attaches it to the context of the Http request.
+// If header is empty then both grpc-timeout, a relative timeout (e.g. 250m or 2S), and X-Request-Deadline,
+// an absolute time in RFC3339 format, are read ; if both are present then the earliest deadline is used.
+// Otherwise only the given header is read, in the grpc-timeout format if it is HEADER_GrpcTimeout or else in RFC3339 format.
+// Requests without the header are passed unchanged. An invalid value results in Http status BadRequest (400)
+// and a deadline that has already passed on arrival results in Http status GatewayTimeout (504).
+func NewDeadlineFilter(header string) FilterFunction {
+	headers := []deadlineHeader{{HEADER_GrpcTimeout, parseGrpcTimeout}, {HEADER_RequestDeadline, parseRFC3339Deadline}}
+	if len(header) > 0 {
+		parse := parseRFC3339Deadline
+		if strings.EqualFold(header, HEADER_GrpcTimeout) {
+			parse = parseGrpcTimeout
+		}
+		headers = []deadlineHeader{{header, parse}}
+	}
+	return func(req *Request, resp *Response, chain *FilterChain) {
+		now := time.Now()
+		var deadline time.Time
+		for _, each := range headers {
+			value := req.HeaderParameter(each.name)
+			if len(value) == 0 {
+				continue
+			}
+			parsed, err := each.parse(value, now)
+			if err != nil {
+				resp.WriteErrorString(http.StatusBadRequest, fmt.Sprintf("400: Invalid value for header %s: %q", each.name, value))
+				return
+			}
+			if deadline.IsZero() || parsed.Before(deadline) {
+				deadline = parsed
+			}
+		}
+		if deadline.IsZero() {
+			chain.ProcessFilter(req, resp)
+			return
+		}
+		if !deadline.After(time.Now()) {
+			resp.WriteErrorString(http.StatusGatewayTimeout, "504: Deadline exceeded before the request was handled")
+			return
+		}
+		ctx, cancel := context.WithDeadline(req.Request.Context(), deadline)
+		defer cancel()
+		req.Request = req.Request.WithContext(ctx)
+		chain.ProcessFilter(req, resp)
+	}
+}
+
+// maxGrpcTimeoutDigits is the maximum number of digits of a grpc-timeout value, as defined by the grpc protocol.
+const maxGrpcTimeoutDigits = 8
+
+// parseGrpcTimeout returns the time at which a timeout in the grpc-timeout format, relative to now, expires.
+func parseGrpcTimeout(value string, now time.Time) (time.Time, error) {
+	digits := len(value) - 1
+	if digits < 1 || digits > maxGrpcTimeoutDigits {
+		return time.Time{}, fmt.Errorf("grpc-timeout must have 1 to %d digits: %q", maxGrpcTimeoutDigits, value)
+	}
+	unit, ok := grpcTimeoutUnits[value[digits]]
+	if !ok {
+		return time.Time{}, fmt.Errorf("unknown grpc-timeout unit: %q", value)
+	}
+	amount := int64(0)
+	for _, each := range value[:digits] {
+		if each < '0' || each > '9' {
+			return time.Time{}, fmt.Errorf("grpc-timeout must be a positive integer: %q", value)
+		}
+		amount = amount*10 + int64(each-'0')
+	}
+	return now.Add(time.Duration(amount) * unit), nil
+}
+
+// parseRFC3339Deadline returns the absolute time, in RFC3339 format, at which a deadline expires.
+func parseRFC3339Deadline(value string, now time.Time) (time.Time, error) {
+	return time.Parse(time.RFC3339Nano, value)
+}
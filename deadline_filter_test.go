@@ -0,0 +1,109 @@
+package restful
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestDeadlineFilter(t *testing.T) {
+	var remaining time.Duration
+	ws := new(WebService).Path("/budget")
+	ws.Filter(NewDeadlineFilter(""))
+	ws.Route(ws.GET("").To(func(req *Request, resp *Response) {
+		remaining = 0
+		if deadline, ok := req.Request.Context().Deadline(); ok {
+			remaining = time.Until(deadline)
+		}
+	}))
+	c := NewContainer().Add(ws)
+	for _, each := range []struct {
+		header, deadline string
+		code             int
+		min, max         time.Duration
+	}{
+		{HEADER_RequestDeadline, "", http.StatusOK, 0, 0},
+		{HEADER_GrpcTimeout, "2S", http.StatusOK, time.Second, 2 * time.Second},
+		{HEADER_RequestDeadline, time.Now().Add(time.Minute).Format(time.RFC3339Nano), http.StatusOK, 59 * time.Second, time.Minute},
+		{HEADER_RequestDeadline, time.Now().Add(-time.Second).Format(time.RFC3339Nano), http.StatusGatewayTimeout, 0, 0},
+		{HEADER_GrpcTimeout, "0m", http.StatusGatewayTimeout, 0, 0},
+		{HEADER_RequestDeadline, "soon", http.StatusBadRequest, 0, 0},
+		{HEADER_RequestDeadline, "2S", http.StatusBadRequest, 0, 0},
+		{HEADER_GrpcTimeout, time.Now().Add(time.Minute).Format(time.RFC3339Nano), http.StatusBadRequest, 0, 0},
+	} {
+		remaining = 0
+		httpRequest, _ := http.NewRequest("GET", "/budget", nil)
+		if len(each.deadline) > 0 {
+			httpRequest.Header.Set(each.header, each.deadline)
+		}
+		httpWriter := httptest.NewRecorder()
+		c.dispatch(httpWriter, httpRequest)
+		if got, want := httpWriter.Code, each.code; got != want {
+			t.Errorf("[%s:%s] got %v want %v", each.header, each.deadline, got, want)
+		}
+		if remaining < each.min || remaining > each.max {
+			t.Errorf("[%s:%s] got remaining %v want between %v and %v", each.header, each.deadline, remaining, each.min, each.max)
+		}
+	}
+
+	// the earliest of both deadlines is used
+	httpRequest, _ := http.NewRequest("GET", "/budget", nil)
+	httpRequest.Header.Set(HEADER_GrpcTimeout, "2S")
+	httpRequest.Header.Set(HEADER_RequestDeadline, time.Now().Add(time.Minute).Format(time.RFC3339Nano))
+	c.dispatch(httptest.NewRecorder(), httpRequest)
+	if remaining < time.Second || remaining > 2*time.Second {
+		t.Errorf("got remaining %v want between 1s and 2s", remaining)
+	}
+}
+
+func TestDeadlineFilterCustomHeader(t *testing.T) {
+	ws := new(WebService).Path("/budget")
+	ws.Filter(NewDeadlineFilter("X-Deadline"))
+	ws.Route(ws.GET("").To(func(req *Request, resp *Response) {}))
+	c := NewContainer().Add(ws)
+	for header, code := range map[string]int{
+		"X-Deadline":       http.StatusGatewayTimeout,
+		HEADER_GrpcTimeout: http.StatusOK, // not read
+	} {
+		httpRequest, _ := http.NewRequest("GET", "/budget", nil)
+		httpRequest.Header.Set(header, time.Now().Add(-time.Second).Format(time.RFC3339))
+		httpWriter := httptest.NewRecorder()
+		c.dispatch(httpWriter, httpRequest)
+		if got, want := httpWriter.Code, code; got != want {
+			t.Errorf("[%s] got %v want %v", header, got, want)
+		}
+	}
+}
+
+func TestParseGrpcTimeout(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	for value, want := range map[string]time.Time{
+		"1H":        now.Add(time.Hour),
+		"3M":        now.Add(3 * time.Minute),
+		"250m":      now.Add(250 * time.Millisecond),
+		"10u":       now.Add(10 * time.Microsecond),
+		"99999999n": now.Add(99999999 * time.Nanosecond),
+	} {
+		got, err := parseGrpcTimeout(value, now)
+		if err != nil || !got.Equal(want) {
+			t.Errorf("[%s] got %v,%v want %v", value, got, err, want)
+		}
+	}
+	for _, each := range []string{"", "S", "-5S", "+5S", "5", "5x", "123456789S", "2026-01-01T13:00:00Z"} {
+		if _, err := parseGrpcTimeout(each, now); err == nil {
+			t.Errorf("[%s] expected error", each)
+		}
+	}
+}
+
+func TestParseRFC3339Deadline(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	got, err := parseRFC3339Deadline("2026-01-01T13:00:00Z", now)
+	if err != nil || !got.Equal(now.Add(time.Hour)) {
+		t.Errorf("got %v,%v want %v", got, err, now.Add(time.Hour))
+	}
+	if _, err := parseRFC3339Deadline("2S", now); err == nil {
+		t.Error("expected error for grpc-timeout value")
+	}
+}
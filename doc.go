@@ -37,6 +37,9 @@ For example, /persons/{name:[A-Z][A-Z]} can be used to restrict values for the p
 Regular expressions must use the standard Go syntax as described in the regexp package. (https://code.google.com/p/re2/wiki/Syntax)
 This feature requires the use of a CurlyRouter.
 
+The last parameter of a Route path can be made optional using the format "uri/{var?}".
+For example, /items/{category?} matches both /items and /items/books ; the value of "category" is empty for the former.
+
 Containers
 
 A Container holds a collection of WebServices, Filters and a http.ServeMux for multiplexing http requests.
@@ -92,6 +95,11 @@ Two encodings are supported: gzip and deflate. To enable this for all responses:
 
 If a Http request includes the Accept-Encoding header then the response content will be compressed using the specified encoding.
 Alternatively, you can create a Filter that performs the encoding and install it per WebService or Route.
+Responses are compressed using gzip.DefaultCompression. To trade CPU for size, install a provider with another level
+(NewSyncPoolCompessorsLevel or NewBoundedCachedCompressorsLevel):
+
+	provider, _ := restful.NewSyncPoolCompessorsLevel(gzip.BestSpeed)
+	restful.SetCompressorProvider(provider)
 
 See the example https://github.com/emicklei/go-restful/blob/master/examples/restful-encoding-filter.go
 
@@ -108,6 +116,15 @@ By installing the filter of a CrossOriginResourceSharing (CORS), your WebService
 	cors := CrossOriginResourceSharing{ExposeHeaders: []string{"X-My-Header"}, CookiesAllowed: false, Container: DefaultContainer}
 	Filter(cors.Filter)
 
+Other MIME types
+
+JSON and XML are supported out of the box. Other representations, such as CBOR (application/cbor) for constrained devices,
+can be added by registering an EntityReaderWriter that wraps the encoding library of your choice.
+
+	restful.RegisterEntityAccessor("application/cbor", myCBORAccess{})
+
+Routes that list this MIME type in Consumes or Produces then read and write entities using it, based on the Content-Type and Accept Headers.
+
 Error Handling
 
 Unexpected things happen. If a request cannot be processed because of a failure, your service needs to tell via the response what happened and why.
@@ -5,10 +5,17 @@ package restful
 // that can be found in the LICENSE file.
 
 import (
+	"bytes"
 	"encoding/json"
 	"encoding/xml"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"reflect"
 	"strings"
 	"sync"
+	"sync/atomic"
 )
 
 // EntityReaderWriter can read and write values using an encoding such as JSON,XML.
@@ -38,19 +45,97 @@ type entityReaderWriters struct {
 func init() {
 	RegisterEntityAccessor(MIME_JSON, entityJSONAccess{ContentType: MIME_JSON})
 	RegisterEntityAccessor(MIME_XML, entityXMLAccess{ContentType: MIME_XML})
+	RegisterEntityAccessor(MIME_NDJSON, entityNDJSONAccess{})
 }
 
 // RegisterEntityAccessor add/overrides the ReaderWriter for encoding content with this MIME type.
 func RegisterEntityAccessor(mime string, erw EntityReaderWriter) {
-	entityAccessRegistry.protection.Lock()
-	defer entityAccessRegistry.protection.Unlock()
-	entityAccessRegistry.accessors[mime] = erw
+	entityAccessRegistry.register(mime, erw)
 }
 
-// AccessorAt returns the registered ReaderWriter for this MIME type.
+// newEntityReaderWriters returns an empty registry.
+func newEntityReaderWriters() *entityReaderWriters {
+	return &entityReaderWriters{protection: new(sync.RWMutex), accessors: map[string]EntityReaderWriter{}}
+}
+
+// register add/overrides the ReaderWriter for this MIME type.
+// MIME types are case-insensitive so the key is stored in lower case.
+func (r *entityReaderWriters) register(mime string, erw EntityReaderWriter) {
+	r.protection.Lock()
+	defer r.protection.Unlock()
+	r.accessors[strings.ToLower(mime)] = erw
+	// invalidate all cached negotiations
+	atomic.AddUint64(&accessorGeneration, 1)
+}
+
+// accessorGeneration is incremented for each registration of an accessor.
+var accessorGeneration uint64
+
+// maxCachedAccessors limits the number of distinct Accept headers remembered per Route.
+const maxCachedAccessors = 64
+
+// accessorCache remembers, for a Route, which EntityReaderWriter was negotiated for an Accept header.
+// Copies of a Route share its cache so results are also keyed by the accessors of the WebService.
+type accessorCache struct {
+	protection *sync.RWMutex
+	generation uint64
+	writers    map[accessorCacheKey]cachedAccessor
+}
+
+type accessorCacheKey struct {
+	accept   string
+	accessor *entityReaderWriters // accessors of the WebService ; nil if none are registered
+}
+
+type cachedAccessor struct {
+	writer EntityReaderWriter
+	ok     bool
+}
+
+func newAccessorCache() *accessorCache {
+	return &accessorCache{protection: new(sync.RWMutex), writers: map[accessorCacheKey]cachedAccessor{}}
+}
+
+// writerFor returns the cached negotiation result for the key or else calls negotiate and caches its result.
+// Cached results are discarded if any accessor was registered since they were cached.
+func (c *accessorCache) writerFor(key accessorCacheKey, negotiate func() (EntityReaderWriter, bool)) (EntityReaderWriter, bool) {
+	generation := atomic.LoadUint64(&accessorGeneration)
+	c.protection.RLock()
+	cached, ok := c.writers[key]
+	current := c.generation == generation
+	c.protection.RUnlock()
+	if ok && current {
+		return cached.writer, cached.ok
+	}
+	writer, found := negotiate()
+	c.protection.Lock()
+	defer c.protection.Unlock()
+	if c.generation != generation {
+		c.writers = map[accessorCacheKey]cachedAccessor{}
+		c.generation = generation
+	}
+	if len(c.writers) < maxCachedAccessors {
+		c.writers[key] = cachedAccessor{writer: writer, ok: found}
+	}
+	return writer, found
+}
+
+// accessorAt returns the ReaderWriter for this MIME type from the service registry (if not nil)
+// or else from the global registry.
+func accessorAt(service *entityReaderWriters, mime string) (EntityReaderWriter, bool) {
+	if service != nil {
+		if erw, ok := service.AccessorAt(mime); ok {
+			return erw, true
+		}
+	}
+	return entityAccessRegistry.AccessorAt(mime)
+}
+
+// AccessorAt returns the registered ReaderWriter for this MIME type, ignoring case.
 func (r *entityReaderWriters) AccessorAt(mime string) (EntityReaderWriter, bool) {
 	r.protection.RLock()
 	defer r.protection.RUnlock()
+	mime = strings.ToLower(mime)
 	er, ok := r.accessors[mime]
 	if !ok {
 		// retry with reverse lookup
@@ -64,6 +149,32 @@ func (r *entityReaderWriters) AccessorAt(mime string) (EntityReaderWriter, bool)
 	return er, ok
 }
 
+// utf8BOM is the byte order mark that some clients write before UTF-8 encoded content.
+var utf8BOM = []byte{0xEF, 0xBB, 0xBF}
+
+// withoutBOM returns a reader of the content that skips a leading UTF-8 byte order mark, if present.
+// Only the first bytes are read to detect it ; these are put back in front of the content if it is not a mark.
+func withoutBOM(content io.Reader) io.Reader {
+	prefix := make([]byte, len(utf8BOM))
+	n, _ := io.ReadFull(content, prefix)
+	if n == len(utf8BOM) && bytes.Equal(prefix, utf8BOM) {
+		return content
+	}
+	return io.MultiReader(bytes.NewReader(prefix[:n]), content)
+}
+
+// emptyBodyChecked translates the io.EOF of a decoder, that did not find any content, into ErrEmptyBody.
+// Returns nil instead if empty content is allowed (see SetAllowEmptyEntityBody).
+func emptyBodyChecked(decodeErr error) error {
+	if decodeErr != io.EOF {
+		return decodeErr
+	}
+	if doAllowEmptyEntityBody {
+		return nil
+	}
+	return ErrEmptyBody
+}
+
 // entityXMLAccess is a EntityReaderWriter for XML encoding
 type entityXMLAccess struct {
 	// This is used for setting the Content-Type header when writing
@@ -72,7 +183,7 @@ type entityXMLAccess struct {
 
 // Read unmarshalls the value from XML
 func (e entityXMLAccess) Read(req *Request, v interface{}) error {
-	return xml.NewDecoder(req.Request.Body).Decode(v)
+	return emptyBodyChecked(xml.NewDecoder(withoutBOM(req.Request.Body)).Decode(v))
 }
 
 // Write marshalls the value to JSON and set the Content-Type Header.
@@ -80,6 +191,111 @@ func (e entityXMLAccess) Write(resp *Response, status int, v interface{}) error
 	return writeXML(resp, status, e.ContentType, v)
 }
 
+// entityXMLElementAccess is a EntityReaderWriter for XML encoding with a custom root element
+type entityXMLElementAccess struct {
+	entityXMLAccess
+	root      xml.Name
+	prefix    string
+	namespace string
+}
+
+// NewEntityAccessorXML returns an EntityReaderWriter for XML that writes values using the root element name,
+// in the namespace (if not empty), instead of the defaults of encoding/xml. Register it for a WebService
+// or globally, e.g. for MIME type "application/soap+xml".
+// If prefix is empty then the namespace is declared as the default namespace (xmlns="..."). Otherwise it is
+// declared for the prefix (xmlns:prefix="...") which is then written for the root element and all elements
+// that are not in a default namespace of their own.
+//
+//	ws.RegisterEntityAccessor(restful.MIME_XML, restful.NewEntityAccessorXML(restful.MIME_XML, "order", "ord", "urn:example:orders"))
+func NewEntityAccessorXML(contentType, rootName, prefix, namespace string) EntityReaderWriter {
+	root := xml.Name{Space: namespace, Local: rootName}
+	if len(prefix) > 0 {
+		// the namespace is declared by encodePrefixed
+		root.Space = ""
+	}
+	return entityXMLElementAccess{
+		entityXMLAccess: entityXMLAccess{ContentType: contentType},
+		root:            root,
+		prefix:          prefix,
+		namespace:       namespace}
+}
+
+// Write marshalls the value to XML using the root element and set the Content-Type Header.
+func (e entityXMLElementAccess) Write(resp *Response, status int, v interface{}) error {
+	if v == nil {
+		resp.WriteHeader(status)
+		// do not write a nil representation
+		return nil
+	}
+	var output bytes.Buffer
+	if len(e.prefix) > 0 {
+		// elements are prefixed after marshalling ; encoding/xml cannot write prefixed element names
+		if err := xml.NewEncoder(&output).EncodeElement(v, xml.StartElement{Name: e.root}); err != nil {
+			return err
+		}
+	}
+	resp.Header().Set(HEADER_ContentType, e.ContentType)
+	resp.WriteHeader(status)
+	encoder := xml.NewEncoder(resp)
+	if resp.prettyPrint {
+		if _, err := resp.Write([]byte(xml.Header)); err != nil {
+			return err
+		}
+		encoder.Indent(prettyPrintPrefix, prettyPrintIndent)
+	}
+	if len(e.prefix) == 0 {
+		return encoder.EncodeElement(v, xml.StartElement{Name: e.root})
+	}
+	if err := e.encodePrefixed(encoder, xml.NewDecoder(&output)); err != nil {
+		return err
+	}
+	return encoder.Flush()
+}
+
+// encodePrefixed copies the XML tokens of the decoder to the encoder, writing the prefix for each element
+// that is not in a default namespace of its own. The namespace of the prefix is declared on the root element.
+func (e entityXMLElementAccess) encodePrefixed(encoder *xml.Encoder, decoder *xml.Decoder) error {
+	// for each open element, whether it or one of its ancestors declares a default namespace
+	ownNamespace := []bool{}
+	for {
+		token, err := decoder.RawToken()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		switch t := token.(type) {
+		case xml.StartElement:
+			own := len(ownNamespace) > 0 && ownNamespace[len(ownNamespace)-1]
+			for _, each := range t.Attr {
+				if each.Name.Space == "" && each.Name.Local == "xmlns" {
+					own = true
+				}
+			}
+			if len(ownNamespace) == 0 {
+				declaration := xml.Attr{Name: xml.Name{Local: "xmlns:" + e.prefix}, Value: e.namespace}
+				t.Attr = append([]xml.Attr{declaration}, t.Attr...)
+			}
+			ownNamespace = append(ownNamespace, own)
+			if !own && len(t.Name.Space) == 0 {
+				t.Name.Local = e.prefix + ":" + t.Name.Local
+			}
+			token = t
+		case xml.EndElement:
+			own := ownNamespace[len(ownNamespace)-1]
+			ownNamespace = ownNamespace[:len(ownNamespace)-1]
+			if !own && len(t.Name.Space) == 0 {
+				t.Name.Local = e.prefix + ":" + t.Name.Local
+			}
+			token = t
+		}
+		if err := encoder.EncodeToken(token); err != nil {
+			return err
+		}
+	}
+}
+
 // writeXML marshalls the value to JSON and set the Content-Type Header.
 func writeXML(resp *Response, status int, contentType string, v interface{}) error {
 	if v == nil {
@@ -89,7 +305,7 @@ func writeXML(resp *Response, status int, contentType string, v interface{}) err
 	}
 	if resp.prettyPrint {
 		// pretty output must be created and written explicitly
-		output, err := xml.MarshalIndent(v, " ", " ")
+		output, err := xml.MarshalIndent(v, prettyPrintPrefix, prettyPrintIndent)
 		if err != nil {
 			return err
 		}
@@ -108,17 +324,88 @@ func writeXML(resp *Response, status int, contentType string, v interface{}) err
 	return xml.NewEncoder(resp).Encode(v)
 }
 
+var prettyPrintPrefix, prettyPrintIndent = " ", " "
+
+// SetPrettyPrintIndent sets the prefix and indent strings used to write pretty JSON and XML output.
+// Default is a single space for both due to backwardcompatibility ; use ("", "  ") for two-space indentation.
+func SetPrettyPrintIndent(prefix, indent string) {
+	prettyPrintPrefix, prettyPrintIndent = prefix, indent
+}
+
+var doDisallowUnknownJSONFields = false
+
+var doUseJSONNumber = true
+
+// SetUseJSONNumber controls whether reading JSON content into an interface{} value
+// produces numbers as json.Number (true) or as float64 (false).
+// Default is true due to backwardcompatibility.
+func SetUseJSONNumber(use bool) {
+	doUseJSONNumber = use
+}
+
+// SetDisallowUnknownJSONFields controls whether reading JSON content into a struct fails if the
+// content has a field that is not known by that struct. If so, ReadEntity returns a ServiceError with status 400.
+// Default is false (lenient) due to backwardcompatibility.
+func SetDisallowUnknownJSONFields(disallow bool) {
+	doDisallowUnknownJSONFields = disallow
+}
+
 // entityJSONAccess is a EntityReaderWriter for JSON encoding
 type entityJSONAccess struct {
 	// This is used for setting the Content-Type header when writing
 	ContentType string
 }
 
+// NewEntityAccessorJSON returns an EntityReaderWriter for JSON that writes the Content-Type, e.g. "application/merge-patch+json".
+func NewEntityAccessorJSON(contentType string) EntityReaderWriter {
+	return entityJSONAccess{ContentType: contentType}
+}
+
 // Read unmarshalls the value from JSON
 func (e entityJSONAccess) Read(req *Request, v interface{}) error {
-	decoder := json.NewDecoder(req.Request.Body)
-	decoder.UseNumber()
-	return decoder.Decode(v)
+	decoder := json.NewDecoder(withoutBOM(req.Request.Body))
+	if doUseJSONNumber {
+		decoder.UseNumber()
+	}
+	if doDisallowUnknownJSONFields {
+		decoder.DisallowUnknownFields()
+	}
+	err := decoder.Decode(v)
+	if err != nil && doDisallowUnknownJSONFields && strings.HasPrefix(err.Error(), "json: unknown field") {
+		return NewError(http.StatusBadRequest, "400: "+err.Error())
+	}
+	return jsonDecodeError(decoder, err)
+}
+
+// jsonDecodeError translates the decoding errors of malformed (SyntaxError) or missing (ErrEmptyBody) content.
+func jsonDecodeError(decoder *json.Decoder, err error) error {
+	if syntaxErr, ok := err.(*json.SyntaxError); ok {
+		return SyntaxError{Offset: syntaxErr.Offset, Err: err}
+	}
+	if err == io.ErrUnexpectedEOF {
+		// the content ended before the value did ; the offset is its length
+		unscanned, _ := io.Copy(ioutil.Discard, decoder.Buffered())
+		return SyntaxError{Offset: decoder.InputOffset() + unscanned, Err: err}
+	}
+	return emptyBodyChecked(err)
+}
+
+// SyntaxError is returned by ReadEntity if the JSON content of the request is malformed.
+// Offset is the number of bytes read before the error was detected.
+// Use Response.WriteReadEntityError to respond with Http Status BadRequest (400).
+type SyntaxError struct {
+	Offset int64
+	Err    error
+}
+
+// Error returns a text representation of the syntax error, including its offset.
+func (s SyntaxError) Error() string {
+	return fmt.Sprintf("malformed JSON at offset %d: %v", s.Offset, s.Err)
+}
+
+// Unwrap returns the error of the decoder, e.g. a *json.SyntaxError.
+func (s SyntaxError) Unwrap() error {
+	return s.Err
 }
 
 // Write marshalls the value to JSON and set the Content-Type Header.
@@ -135,7 +422,7 @@ func writeJSON(resp *Response, status int, contentType string, v interface{}) er
 	}
 	if resp.prettyPrint {
 		// pretty output must be created and written explicitly
-		output, err := json.MarshalIndent(v, " ", " ")
+		output, err := json.MarshalIndent(v, prettyPrintPrefix, prettyPrintIndent)
 		if err != nil {
 			return err
 		}
@@ -149,3 +436,64 @@ func writeJSON(resp *Response, status int, contentType string, v interface{}) er
 	resp.WriteHeader(status)
 	return json.NewEncoder(resp).Encode(v)
 }
+
+// entityNDJSONAccess is a EntityReaderWriter for newline delimited JSON encoding.
+// Use Request.ReadEntities to read all values one at a time.
+type entityNDJSONAccess struct{}
+
+// Read unmarshalls the first value from newline delimited JSON
+func (e entityNDJSONAccess) Read(req *Request, v interface{}) error {
+	return entityJSONAccess{}.Read(req, v)
+}
+
+// Write marshalls the value to JSON on a single line ; if the value is a slice then each element is written on its own line.
+func (e entityNDJSONAccess) Write(resp *Response, status int, v interface{}) error {
+	if v == nil {
+		resp.WriteHeader(status)
+		// do not write a nil representation
+		return nil
+	}
+	resp.Header().Set(HEADER_ContentType, MIME_NDJSON)
+	resp.WriteHeader(status)
+	// Encode terminates each value with a newline
+	encoder := json.NewEncoder(resp)
+	if rv := reflect.ValueOf(v); rv.Kind() == reflect.Slice {
+		for i := 0; i < rv.Len(); i++ {
+			if err := encoder.Encode(rv.Index(i).Interface()); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return encoder.Encode(v)
+}
+
+// entityReadLimitAccess is a EntityReaderWriter that limits the size of the content its delegate reads
+type entityReadLimitAccess struct {
+	EntityReaderWriter
+	maxBytes int64
+}
+
+// NewEntityAccessorWithReadLimit returns an EntityReaderWriter that uses erw but refuses to Read content
+// of more than maxBytes (after decompression) with a ServiceError with Http Status RequestEntityTooLarge (413).
+// Use it to set a limit per MIME type, e.g. a small one for JSON patches and a large one for CSV uploads.
+//
+//	ws.RegisterEntityAccessor(restful.MIME_JSON, restful.NewEntityAccessorWithReadLimit(restful.NewEntityAccessorJSON(restful.MIME_JSON), 64*1024))
+func NewEntityAccessorWithReadLimit(erw EntityReaderWriter, maxBytes int64) EntityReaderWriter {
+	return entityReadLimitAccess{EntityReaderWriter: erw, maxBytes: maxBytes}
+}
+
+// Read reads up to maxBytes of content and passes it to the delegate.
+func (e entityReadLimitAccess) Read(req *Request, v interface{}) error {
+	// read one more byte to detect content beyond the limit
+	data, err := ioutil.ReadAll(io.LimitReader(req.Request.Body, e.maxBytes+1))
+	if err != nil {
+		return err
+	}
+	if int64(len(data)) > e.maxBytes {
+		return NewError(http.StatusRequestEntityTooLarge,
+			fmt.Sprintf("413: Request Entity Too Large, content exceeds the limit of %d bytes", e.maxBytes))
+	}
+	req.Request.Body = ioutil.NopCloser(bytes.NewReader(data))
+	return e.EntityReaderWriter.Read(req, v)
+}
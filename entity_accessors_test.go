@@ -2,11 +2,15 @@ package restful
 
 import (
 	"bytes"
+	"encoding/json"
+	"encoding/xml"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
 	"reflect"
+	"strings"
 	"testing"
 )
 
@@ -49,7 +53,7 @@ func TestKeyValueEncoding(t *testing.T) {
 	// Write
 	httpWriter := httptest.NewRecorder()
 	//								Accept									Produces
-	resp := Response{httpWriter, "application/kv,*/*;q=0.8", []string{"application/kv"}, 0, 0, true, nil}
+	resp := Response{ResponseWriter: httpWriter, requestAccept: "application/kv,*/*;q=0.8", routeProduces: []string{"application/kv"}, prettyPrint: true}
 	resp.WriteEntity(b)
 	t.Log(string(httpWriter.Body.Bytes()))
 	if !kv.writeCalled {
@@ -67,3 +71,335 @@ func TestKeyValueEncoding(t *testing.T) {
 		t.Error("Read never called")
 	}
 }
+
+func TestReadEntityJSONUnknownField(t *testing.T) {
+	for _, strict := range []bool{false, true} {
+		SetDisallowUnknownJSONFields(strict)
+		httpRequest, _ := http.NewRequest("POST", "/test", strings.NewReader(`{"Value":"42","Valeu":"typo"}`))
+		httpRequest.Header.Set("Content-Type", MIME_JSON)
+		sam := new(Sample)
+		err := NewRequest(httpRequest).ReadEntity(sam)
+		if !strict {
+			if err != nil || sam.Value != "42" {
+				t.Errorf("lenient: unexpected error %v or value %q", err, sam.Value)
+			}
+			continue
+		}
+		ser, ok := err.(ServiceError)
+		if !ok {
+			t.Fatalf("strict: expected ServiceError, got %v", err)
+		}
+		if ser.Code != http.StatusBadRequest || !strings.Contains(ser.Message, `"Valeu"`) {
+			t.Errorf("strict: unexpected error %v", ser)
+		}
+	}
+	SetDisallowUnknownJSONFields(false)
+}
+
+func TestReadEntityJSONUseNumber(t *testing.T) {
+	for _, useNumber := range []bool{true, false} {
+		SetUseJSONNumber(useNumber)
+		httpRequest, _ := http.NewRequest("POST", "/test", strings.NewReader(`{"Value":42}`))
+		httpRequest.Header.Set("Content-Type", MIME_JSON)
+		var doc interface{}
+		if err := NewRequest(httpRequest).ReadEntity(&doc); err != nil {
+			t.Fatal(err)
+		}
+		value := doc.(map[string]interface{})["Value"]
+		if _, isNumber := value.(json.Number); isNumber != useNumber {
+			t.Errorf("[useNumber:%v] got %T", useNumber, value)
+		}
+		if _, isFloat := value.(float64); isFloat == useNumber {
+			t.Errorf("[useNumber:%v] got %T", useNumber, value)
+		}
+	}
+	SetUseJSONNumber(true)
+}
+
+// go test -v -test.run TestPrettyPrintIndent ...restful
+func TestPrettyPrintIndent(t *testing.T) {
+	SetPrettyPrintIndent("", "\t")
+	defer SetPrettyPrintIndent(" ", " ")
+	for mime, want := range map[string]string{
+		MIME_JSON: "{\n\t\"Kind\": \"Juicy\"\n}",
+		MIME_XML:  "<food>\n\t<Kind>Juicy</Kind>\n</food>",
+	} {
+		httpWriter := httptest.NewRecorder()
+		resp := Response{ResponseWriter: httpWriter, requestAccept: mime, routeProduces: []string{mime}, prettyPrint: true}
+		resp.WriteEntity(food{"Juicy"})
+		if got := strings.TrimPrefix(httpWriter.Body.String(), xml.Header); got != want {
+			t.Errorf("[%s] got %q want %q", mime, got, want)
+		}
+	}
+}
+
+// labelAccess is an EntityReaderWriter for testing that reads its label into a *string and writes it before the value.
+type labelAccess struct{ label string }
+
+func (l labelAccess) Read(req *Request, v interface{}) error {
+	*(v.(*string)) = l.label
+	return nil
+}
+
+func (l labelAccess) Write(resp *Response, status int, v interface{}) error {
+	resp.WriteHeader(status)
+	_, err := io.WriteString(resp, l.label+":"+fmt.Sprint(v))
+	return err
+}
+
+// go test -v -test.run TestWebServiceEntityAccessors ...restful
+func TestWebServiceEntityAccessors(t *testing.T) {
+	c := NewContainer()
+	for _, label := range []string{"one", "two"} {
+		ws := new(WebService).Path("/" + label).Consumes("application/label").Produces("application/label")
+		ws.RegisterEntityAccessor("application/label", labelAccess{label})
+		ws.Route(ws.POST("").To(func(req *Request, resp *Response) {
+			var read string
+			req.ReadEntity(&read)
+			resp.WriteEntity(read)
+		}))
+		c.Add(ws)
+	}
+	for _, label := range []string{"one", "two"} {
+		httpRequest, _ := http.NewRequest("POST", "/"+label, strings.NewReader("?"))
+		httpRequest.Header.Set("Content-Type", "application/label")
+		httpRequest.Header.Set("Accept", "application/label")
+		httpWriter := httptest.NewRecorder()
+		c.dispatch(httpWriter, httpRequest)
+		if got, want := httpWriter.Body.String(), label+":"+label; got != want {
+			t.Errorf("got %v want %v", got, want)
+		}
+	}
+	if _, ok := entityAccessRegistry.AccessorAt("application/label"); ok {
+		t.Error("service accessor must not be registered globally")
+	}
+}
+
+// go test -v -test.run TestRouteAccessorCacheInvalidation ...restful
+func TestRouteAccessorCacheInvalidation(t *testing.T) {
+	defer func() {
+		entityAccessRegistry.protection.Lock()
+		delete(entityAccessRegistry.accessors, "application/label")
+		entityAccessRegistry.protection.Unlock()
+	}()
+	c := NewContainer()
+	ws := new(WebService).Path("/labels").Produces("application/label")
+	ws.Route(ws.GET("").To(func(req *Request, resp *Response) {
+		resp.WriteEntity("x")
+	}))
+	c.Add(ws)
+	for _, label := range []string{"first", "second"} {
+		RegisterEntityAccessor("application/label", labelAccess{label})
+		for i := 0; i < 2; i++ {
+			httpRequest, _ := http.NewRequest("GET", "/labels", nil)
+			httpRequest.Header.Set("Accept", "application/label")
+			httpWriter := httptest.NewRecorder()
+			c.dispatch(httpWriter, httpRequest)
+			if got, want := httpWriter.Body.String(), label+":x"; got != want {
+				t.Errorf("got %v want %v", got, want)
+			}
+		}
+	}
+	if got, want := len(ws.Routes()[0].accessors.writers), 1; got != want {
+		t.Errorf("got %v want %v cached writers", got, want)
+	}
+}
+
+// go test -v -test.run TestRouteAccessorCacheSharedRoute ...restful
+func TestRouteAccessorCacheSharedRoute(t *testing.T) {
+	one := new(WebService).Path("/one").Produces("application/label")
+	one.RegisterEntityAccessor("application/label", labelAccess{"one"})
+	one.Route(one.GET("").To(func(req *Request, resp *Response) {
+		resp.WriteEntity("x")
+	}))
+	// a copy of the Route shares its accessor cache
+	two := new(WebService).Path("/one")
+	two.RegisterEntityAccessor("application/label", labelAccess{"two"})
+	two.routes = one.Routes()
+	for _, each := range []struct {
+		ws    *WebService
+		label string
+	}{{one, "one"}, {two, "two"}} {
+		c := NewContainer()
+		c.Add(each.ws)
+		httpRequest, _ := http.NewRequest("GET", "/one", nil)
+		httpRequest.Header.Set("Accept", "application/label")
+		httpWriter := httptest.NewRecorder()
+		c.dispatch(httpWriter, httpRequest)
+		if got, want := httpWriter.Body.String(), each.label+":x"; got != want {
+			t.Errorf("got %v want %v", got, want)
+		}
+	}
+}
+
+// go test -v -test.run TestReadEntityMalformedJSON ...restful
+func TestReadEntityMalformedJSON(t *testing.T) {
+	for body, offset := range map[string]int64{
+		`{"Value":"42",}`: 15,
+		`{"Value":"42"`:   13,
+	} {
+		httpRequest, _ := http.NewRequest("POST", "/test", strings.NewReader(body))
+		httpRequest.Header.Set("Content-Type", MIME_JSON)
+		err := NewRequest(httpRequest).ReadEntity(new(Sample))
+		syntaxErr, ok := err.(SyntaxError)
+		if !ok {
+			t.Fatalf("[%s] expected SyntaxError, got %v", body, err)
+		}
+		if got, want := syntaxErr.Offset, offset; got != want {
+			t.Errorf("[%s] got %v want %v", body, got, want)
+		}
+
+		httpWriter := httptest.NewRecorder()
+		NewResponse(httpWriter).WriteReadEntityError(err)
+		if got, want := httpWriter.Code, http.StatusBadRequest; got != want {
+			t.Errorf("[%s] got %v want %v", body, got, want)
+		}
+		if got, want := httpWriter.Body.String(), fmt.Sprintf("400: malformed JSON at offset %d", offset); !strings.HasPrefix(got, want) {
+			t.Errorf("[%s] got %v want %v", body, got, want)
+		}
+	}
+}
+
+// go test -v -test.run TestEntityAccessorXMLRootElement ...restful
+func TestEntityAccessorXMLRootElement(t *testing.T) {
+	type order struct {
+		XMLName xml.Name `xml:"Order"`
+		ID      string   `xml:"id"`
+	}
+	accessor := NewEntityAccessorXML(MIME_XML, "purchase", "", "urn:example:orders")
+	for pretty, want := range map[bool]string{
+		false: `<purchase xmlns="urn:example:orders"><id>42</id></purchase>`,
+		true:  xml.Header + " <purchase xmlns=\"urn:example:orders\">\n  <id>42</id>\n </purchase>",
+	} {
+		httpWriter := httptest.NewRecorder()
+		resp := NewResponse(httpWriter)
+		resp.PrettyPrint(pretty)
+		if err := accessor.Write(resp, http.StatusOK, order{ID: "42"}); err != nil {
+			t.Fatal(err)
+		}
+		if got := httpWriter.Body.String(); got != want {
+			t.Errorf("[pretty=%v] got %q want %q", pretty, got, want)
+		}
+		if got, want := httpWriter.Header().Get(HEADER_ContentType), MIME_XML; got != want {
+			t.Errorf("got %v want %v", got, want)
+		}
+	}
+	httpRequest, _ := http.NewRequest("POST", "/orders", strings.NewReader(`<purchase xmlns="urn:example:orders"><id>7</id></purchase>`))
+	var read struct {
+		ID string `xml:"id"`
+	}
+	if err := accessor.Read(NewRequest(httpRequest), &read); err != nil || read.ID != "7" {
+		t.Errorf("got %v,%v want 7", read.ID, err)
+	}
+}
+
+// go test -v -test.run TestReadEntityWithBOM ...restful
+func TestReadEntityWithBOM(t *testing.T) {
+	for mime, body := range map[string]string{
+		MIME_JSON: "\xEF\xBB\xBF \r\n{\"Value\":\"42\"}\n",
+		MIME_XML:  "\xEF\xBB\xBF\n<Sample><Value>42</Value></Sample>\n",
+	} {
+		httpRequest, _ := http.NewRequest("POST", "/test", strings.NewReader(body))
+		httpRequest.Header.Set("Content-Type", mime)
+		sam := new(Sample)
+		if err := NewRequest(httpRequest).ReadEntity(sam); err != nil {
+			t.Fatalf("[%s] unexpected error %v", mime, err)
+		}
+		if got, want := sam.Value, "42"; got != want {
+			t.Errorf("[%s] got %v want %v", mime, got, want)
+		}
+	}
+}
+
+func TestEntityAccessorXMLNamespacePrefix(t *testing.T) {
+	type line struct {
+		Product string `xml:"urn:example:products product"`
+	}
+	type order struct {
+		ID    string `xml:"id,attr"`
+		Lines []line `xml:"line"`
+	}
+	accessor := NewEntityAccessorXML(MIME_XML, "purchase", "ord", "urn:example:orders")
+	value := order{ID: "42", Lines: []line{{"apple"}}}
+	for pretty, want := range map[bool]string{
+		false: `<ord:purchase xmlns:ord="urn:example:orders" id="42"><ord:line><product xmlns="urn:example:products">apple</product></ord:line></ord:purchase>`,
+		true: xml.Header + " <ord:purchase xmlns:ord=\"urn:example:orders\" id=\"42\">\n" +
+			"  <ord:line>\n   <product xmlns=\"urn:example:products\">apple</product>\n  </ord:line>\n </ord:purchase>",
+	} {
+		httpWriter := httptest.NewRecorder()
+		resp := NewResponse(httpWriter)
+		resp.PrettyPrint(pretty)
+		if err := accessor.Write(resp, http.StatusOK, value); err != nil {
+			t.Fatal(err)
+		}
+		if got := httpWriter.Body.String(); got != want {
+			t.Errorf("[pretty=%v] got %q want %q", pretty, got, want)
+		}
+	}
+	httpRequest, _ := http.NewRequest("POST", "/orders", strings.NewReader(
+		`<ord:purchase xmlns:ord="urn:example:orders" id="7"><ord:line><product xmlns="urn:example:products">pear</product></ord:line></ord:purchase>`))
+	read := new(order)
+	if err := accessor.Read(NewRequest(httpRequest), read); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := read.ID+" "+read.Lines[0].Product, "7 pear"; got != want {
+		t.Errorf("got %v want %v", got, want)
+	}
+}
+
+func TestWithoutBOM(t *testing.T) {
+	for content, want := range map[string]string{
+		"":               "",
+		"{}":             "{}",
+		"\xEF\xBB":       "\xEF\xBB",
+		"\xEF\xBB\xBF":   "",
+		"\xEF\xBB\xBF{}": "{}",
+		"[1,2,3]":        "[1,2,3]",
+	} {
+		data, err := ioutil.ReadAll(withoutBOM(strings.NewReader(content)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := string(data); got != want {
+			t.Errorf("[%q] got %q want %q", content, got, want)
+		}
+	}
+}
+
+func TestEntityAccessorWithReadLimit(t *testing.T) {
+	ws := new(WebService).Path("/uploads")
+	ws.RegisterEntityAccessor(MIME_JSON, NewEntityAccessorWithReadLimit(NewEntityAccessorJSON(MIME_JSON), 1024))
+	ws.Route(ws.POST("").Consumes(MIME_JSON, MIME_XML).To(func(req *Request, resp *Response) {
+		sam := new(Sample)
+		if err := req.ReadEntity(sam); err != nil {
+			resp.WriteReadEntityError(err)
+			return
+		}
+		io.WriteString(resp, fmt.Sprintf("%d", len(sam.Value)))
+	}))
+	c := NewContainer().Add(ws)
+	for _, each := range []struct {
+		mime, value string
+		code        int
+		body        string
+	}{
+		{MIME_JSON, strings.Repeat("a", 1000), http.StatusOK, "1000"},
+		{MIME_JSON, strings.Repeat("a", 1100), http.StatusRequestEntityTooLarge, "413: Request Entity Too Large, content exceeds the limit of 1024 bytes"},
+		{MIME_XML, strings.Repeat("a", 1100), http.StatusOK, "1100"}, // other accessor, no limit
+	} {
+		content, _ := json.Marshal(Sample{Value: each.value})
+		if each.mime == MIME_XML {
+			content, _ = xml.Marshal(Sample{Value: each.value})
+		}
+		httpRequest, _ := http.NewRequest("POST", "/uploads", bytes.NewReader(content))
+		httpRequest.Header.Set(HEADER_ContentType, each.mime)
+		httpWriter := httptest.NewRecorder()
+		c.dispatch(httpWriter, httpRequest)
+		if got, want := httpWriter.Code, each.code; got != want {
+			t.Errorf("[%s %d] got %v want %v", each.mime, len(content), got, want)
+		}
+		if got, want := httpWriter.Body.String(), each.body; got != want {
+			t.Errorf("[%s %d] got %v want %v", each.mime, len(content), got, want)
+		}
+	}
+}
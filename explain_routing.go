@@ -0,0 +1,78 @@
+package restful
+
+// Copyright 2026 agent. All rights reserved.
+// Use of this source code is governed by a license
+// that can be found in the LICENSE file.
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/emicklei/go-restful/log"
+)
+
+// SetExplainRouting enables logging, for each request that matches the root path of this WebService but none
+// of its Routes, why each of its Routes was rejected: a method, path, Content-Type or Accept mismatch.
+// This is meant for diagnosing unexpected 404, 405, 406 and 415 responses and should not be enabled in production.
+func (w *WebService) SetExplainRouting(enabled bool) *WebService {
+	w.explainRouting = enabled
+	return w
+}
+
+// logRoutingExplanation logs why the Routes of the WebService do not match the request.
+func (w *WebService) logRoutingExplanation(httpRequest *http.Request) {
+	lines := []string{}
+	for _, each := range w.Routes() {
+		lines = append(lines, each.String()+": "+explainRouteMismatch(each, httpRequest))
+	}
+	log.Printf("[restful] no route of WebService %s matches %s %s:\n\t%s",
+		w.RootPath(), httpRequest.Method, httpRequest.URL.Path, strings.Join(lines, "\n\t"))
+}
+
+// explainRouteMismatch returns the first reason why the route does not match the request.
+func explainRouteMismatch(route Route, httpRequest *http.Request) string {
+	if reason, ok := explainPathMismatch(route.pathParts, tokenizePath(httpRequest.URL.Path)); ok {
+		return reason
+	}
+	if route.Method != httpRequest.Method {
+		return "method mismatch, route has " + route.Method
+	}
+	if contentType := httpRequest.Header.Get(HEADER_ContentType); !route.matchesContentType(contentType) {
+		return fmt.Sprintf("content-type mismatch, %q is not in consumes [%s]", contentType, strings.Join(route.Consumes, ","))
+	}
+	if accept := httpRequest.Header.Get(HEADER_Accept); !route.matchesAccept(accept) {
+		return fmt.Sprintf("accept mismatch, %q does not match produces [%s]", accept, strings.Join(route.Produces, ","))
+	}
+	return "matches"
+}
+
+// explainPathMismatch returns which segment (counting from 1) of the request path does not match the route tokens, if any.
+func explainPathMismatch(routeTokens, requestTokens []string) (string, bool) {
+	for i, routeToken := range routeTokens {
+		if i == len(requestTokens) {
+			if i == len(routeTokens)-1 && isOptionalParameterToken(routeToken) {
+				return "", false
+			}
+			return fmt.Sprintf("path mismatch, request has %d segments but route has %d", len(requestTokens), len(routeTokens)), true
+		}
+		requestToken := requestTokens[i]
+		if strings.HasPrefix(routeToken, "{") {
+			if colon := strings.Index(routeToken, ":"); colon != -1 {
+				matchesToken, matchesRemainder := CurlyRouter{}.regularMatchesPathToken(routeToken, colon, requestToken)
+				if !matchesToken {
+					return fmt.Sprintf("path mismatch at segment %d, %q does not match %s", i+1, requestToken, routeToken), true
+				}
+				if matchesRemainder {
+					return "", false
+				}
+			}
+		} else if requestToken != routeToken {
+			return fmt.Sprintf("path mismatch at segment %d, %q is not %q", i+1, requestToken, routeToken), true
+		}
+	}
+	if len(requestTokens) > len(routeTokens) {
+		return fmt.Sprintf("path mismatch, request has %d segments but route has %d", len(requestTokens), len(routeTokens)), true
+	}
+	return "", false
+}
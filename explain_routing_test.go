@@ -0,0 +1,61 @@
+package restful
+
+import (
+	"bytes"
+	stdlog "log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/emicklei/go-restful/log"
+)
+
+func TestExplainRouting(t *testing.T) {
+	buffer := new(bytes.Buffer)
+	previous := log.Logger
+	log.SetLogger(stdlog.New(buffer, "", 0))
+	defer log.SetLogger(previous)
+
+	c := NewContainer()
+	ws := new(WebService).Path("/users").SetExplainRouting(true)
+	ws.Route(ws.GET("/{id:[0-9]+}/posts").To(doNothing))
+	ws.Route(ws.POST("/{id}/posts").Consumes(MIME_JSON).To(doNothing))
+	ws.Route(ws.PUT("/{id}").To(doNothing))
+	c.Add(ws)
+	other := new(WebService).Path("/orders").SetExplainRouting(true)
+	other.Route(other.GET("").To(doNothing))
+	c.Add(other)
+
+	httpRequest, _ := http.NewRequest("POST", "/users/42/posts", strings.NewReader("a,b"))
+	httpRequest.Header.Set(HEADER_ContentType, "text/csv")
+	c.dispatch(httptest.NewRecorder(), httpRequest)
+	explanation := buffer.String()
+	for _, want := range []string{
+		"no route of WebService /users matches POST /users/42/posts",
+		"GET /users/{id:[0-9]+}/posts: method mismatch, route has GET",
+		`POST /users/{id}/posts: content-type mismatch, "text/csv" is not in consumes [application/json]`,
+		"PUT /users/{id}: path mismatch, request has 3 segments but route has 2",
+	} {
+		if !strings.Contains(explanation, want) {
+			t.Errorf("missing %q in %s", want, explanation)
+		}
+	}
+	if unwanted := "WebService /orders"; strings.Contains(explanation, unwanted) {
+		t.Errorf("unexpected %q in %s", unwanted, explanation)
+	}
+
+	buffer.Reset()
+	httpRequest, _ = http.NewRequest("GET", "/users/me/posts", nil)
+	c.dispatch(httptest.NewRecorder(), httpRequest)
+	if want := `GET /users/{id:[0-9]+}/posts: path mismatch at segment 2, "me" does not match {id:[0-9]+}`; !strings.Contains(buffer.String(), want) {
+		t.Errorf("missing %q in %s", want, buffer.String())
+	}
+
+	buffer.Reset()
+	httpRequest, _ = http.NewRequest("PUT", "/users/42", nil)
+	c.dispatch(httptest.NewRecorder(), httpRequest)
+	if got := buffer.String(); got != "" {
+		t.Errorf("unexpected explanation for a match:%s", got)
+	}
+}
@@ -4,18 +4,32 @@ package restful
 // Use of this source code is governed by a license
 // that can be found in the LICENSE file.
 
+import "github.com/emicklei/go-restful/log"
+
 // FilterChain is a request scoped object to process one or more filters before calling the target RouteFunction.
 type FilterChain struct {
 	Filters []FilterFunction // ordered list of FilterFunction
 	Index   int              // index into filters that is currently in progress
 	Target  RouteFunction    // function to call after passing all filters
+
+	depth int // number of filters that are currently in progress
+	calls int // number of calls to ProcessFilter
 }
 
 // ProcessFilter passes the request,response pair through the next of Filters.
 // Each filter can decide to proceed to the next Filter or handle the Response itself.
+// A filter must call ProcessFilter at most once ; any next call is logged and ignored.
 func (f *FilterChain) ProcessFilter(request *Request, response *Response) {
+	// the innermost filter in progress is the caller ; it may proceed only once
+	if f.calls > f.depth {
+		log.Printf("[restful] filter at index %d called ProcessFilter more than once ; call ignored", f.depth-1)
+		return
+	}
+	f.calls++
 	if f.Index < len(f.Filters) {
 		f.Index++
+		f.depth++
+		defer func() { f.depth-- }()
 		f.Filters[f.Index-1](request, response, f)
 	} else {
 		f.Target(request, response)
@@ -139,3 +139,81 @@ func sendItTo(address string, container *Container) string {
 	container.dispatch(httpWriter, httpRequest)
 	return httpWriter.Body.String()
 }
+
+func TestWebServiceFilters(t *testing.T) {
+	ws := new(WebService)
+	if got := len(ws.Filters()); got != 0 {
+		t.Fatalf("got %d filters want 0", got)
+	}
+	ws.Filter(serviceFilter).Filter(routeFilter)
+	filters := ws.Filters()
+	if got := len(filters); got != 2 {
+		t.Fatalf("got %d filters want 2", got)
+	}
+	filters[0] = nil
+	if ws.Filters()[0] == nil {
+		t.Error("expected a copy of the filters")
+	}
+}
+
+// go test -v -test.run TestFilterChainProceedsOnce ...restful
+func TestFilterChainProceedsOnce(t *testing.T) {
+	proceed := func(req *Request, resp *Response, chain *FilterChain) {
+		io.WriteString(resp.ResponseWriter, "proceed-")
+		chain.ProcessFilter(req, resp)
+	}
+	proceedTwice := func(req *Request, resp *Response, chain *FilterChain) {
+		io.WriteString(resp.ResponseWriter, "twice-")
+		chain.ProcessFilter(req, resp)
+		chain.ProcessFilter(req, resp)
+	}
+	stop := func(req *Request, resp *Response, chain *FilterChain) {
+		io.WriteString(resp.ResponseWriter, "stop")
+	}
+	for _, each := range []struct {
+		filters []FilterFunction
+		want    string
+	}{
+		{[]FilterFunction{proceed, proceed}, "proceed-proceed-foo"},
+		{[]FilterFunction{proceedTwice}, "twice-foo"},
+		{[]FilterFunction{proceedTwice, proceed}, "twice-proceed-foo"},
+		{[]FilterFunction{proceedTwice, stop}, "twice-stop"},
+		{[]FilterFunction{proceed, proceedTwice, stop}, "proceed-twice-stop"},
+	} {
+		httpWriter := httptest.NewRecorder()
+		chain := FilterChain{Filters: each.filters, Target: foo}
+		chain.ProcessFilter(NewRequest(new(http.Request)), NewResponse(httpWriter))
+		if got := httpWriter.Body.String(); got != each.want {
+			t.Errorf("got %v want %v", got, each.want)
+		}
+	}
+}
+
+func TestFilterInspectsWritten(t *testing.T) {
+	for _, each := range []struct {
+		target RouteFunction
+		want   int
+	}{
+		{doNothing, http.StatusTeapot},
+		{func(req *Request, resp *Response) { resp.WriteHeader(http.StatusAccepted) }, http.StatusAccepted},
+		{func(req *Request, resp *Response) { io.WriteString(resp, "body") }, http.StatusOK},
+	} {
+		var before bool
+		fallback := func(req *Request, resp *Response, chain *FilterChain) {
+			before = resp.Written()
+			chain.ProcessFilter(req, resp)
+			if !resp.Written() {
+				resp.WriteHeader(http.StatusTeapot)
+			}
+		}
+		httpWriter := httptest.NewRecorder()
+		chain := FilterChain{Filters: []FilterFunction{fallback}, Target: each.target}
+		chain.ProcessFilter(NewRequest(new(http.Request)), NewResponse(httpWriter))
+		if before {
+			t.Error("response written before the target was called")
+		}
+		if got := httpWriter.Code; got != each.want {
+			t.Errorf("got %v want %v", got, each.want)
+		}
+	}
+}
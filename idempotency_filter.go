@@ -0,0 +1,152 @@
+package restful
+
+// Copyright 2026 agent. All rights reserved.
+// Use of this source code is governed by a license
+// that can be found in the LICENSE file.
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"sync"
+)
+
+// IdempotentResponse is the recorded status, headers and content of a response, to replay for a retried request.
+// RequestDigest is the SHA-256 (hex) of the content of the request that produced it.
+type IdempotentResponse struct {
+	StatusCode    int
+	Header        http.Header
+	Body          []byte
+	RequestDigest string
+}
+
+// IdempotencyStore keeps the responses of requests by their idempotency key.
+// Implementations must be safe for concurrent use ; they decide how long responses are kept.
+type IdempotencyStore interface {
+	// Get returns the response that was stored for the key, if any.
+	Get(key string) (*IdempotentResponse, bool)
+	// Put stores the response for the key.
+	Put(key string, response *IdempotentResponse)
+}
+
+// memoryIdempotencyStore is an IdempotencyStore that keeps all responses in memory.
+type memoryIdempotencyStore struct {
+	protection *sync.RWMutex
+	responses  map[string]*IdempotentResponse
+}
+
+// NewMemoryIdempotencyStore returns an IdempotencyStore that keeps all responses in memory, without expiration.
+// It is meant for testing and single instance services with few keys.
+func NewMemoryIdempotencyStore() IdempotencyStore {
+	return memoryIdempotencyStore{protection: new(sync.RWMutex), responses: map[string]*IdempotentResponse{}}
+}
+
+// Get is part of IdempotencyStore
+func (m memoryIdempotencyStore) Get(key string) (*IdempotentResponse, bool) {
+	m.protection.RLock()
+	defer m.protection.RUnlock()
+	response, ok := m.responses[key]
+	return response, ok
+}
+
+// Put is part of IdempotencyStore
+func (m memoryIdempotencyStore) Put(key string, response *IdempotentResponse) {
+	m.protection.Lock()
+	defer m.protection.Unlock()
+	m.responses[key] = response
+}
+
+// NewIdempotencyFilter returns a filter that, for requests with an unsafe method (e.g. POST) and a key in the header
+// (default is HEADER_IdempotencyKey), replays the response stored for the same method, path and key.
+// Otherwise the request is passed on and its response is stored, unless it has a status of 500 or above.
+// A retry with the same key but different content is rejected with 422 (Unprocessable Entity).
+// Requests with the same key that are handled concurrently are not detected as retries.
+func NewIdempotencyFilter(store IdempotencyStore, header string) FilterFunction {
+	if len(header) == 0 {
+		header = HEADER_IdempotencyKey
+	}
+	return func(req *Request, resp *Response, chain *FilterChain) {
+		key := req.Request.Header.Get(header)
+		if len(key) == 0 || isSafeMethod(req.Request.Method) {
+			chain.ProcessFilter(req, resp)
+			return
+		}
+		key = req.Request.Method + " " + req.Request.URL.Path + " " + key
+		var content []byte
+		if req.Request.Body != nil {
+			var err error
+			if content, err = req.BodyBytes(); err != nil {
+				resp.WriteErrorString(http.StatusBadRequest, "400: Unable to read request content")
+				return
+			}
+		}
+		sum := sha256.Sum256(content)
+		digest := hex.EncodeToString(sum[:])
+		if stored, ok := store.Get(key); ok {
+			if stored.RequestDigest != digest {
+				resp.WriteErrorString(http.StatusUnprocessableEntity, "422: Idempotency key was used for a different request content")
+				return
+			}
+			for name, values := range stored.Header {
+				resp.Header()[name] = append([]string{}, values...)
+			}
+			resp.WriteHeader(stored.StatusCode)
+			resp.Write(stored.Body)
+			return
+		}
+		recorder := &recordingResponseWriter{ResponseWriter: resp.ResponseWriter, statusCode: http.StatusOK}
+		resp.ResponseWriter = recorder
+		chain.ProcessFilter(req, resp)
+		resp.ResponseWriter = recorder.ResponseWriter
+		if recorder.statusCode >= http.StatusInternalServerError {
+			return
+		}
+		stored := &IdempotentResponse{
+			StatusCode:    recorder.statusCode,
+			Header:        replayableHeader(resp.Header()),
+			Body:          recorder.body.Bytes(),
+			RequestDigest: digest}
+		store.Put(key, stored)
+	}
+}
+
+// replayableHeader returns a copy of the response header without those that depend on the encoding of the response.
+func replayableHeader(header http.Header) http.Header {
+	copied := http.Header{}
+	for name, values := range header {
+		if name != HEADER_ContentEncoding && name != "Content-Length" {
+			copied[name] = append([]string{}, values...)
+		}
+	}
+	return copied
+}
+
+// isSafeMethod returns whether the Http method is defined as safe, i.e. read-only.
+func isSafeMethod(method string) bool {
+	return method == "GET" || method == "HEAD" || method == "OPTIONS" || method == "TRACE"
+}
+
+// recordingResponseWriter is a http.ResponseWriter that keeps a copy of the status and content it writes.
+type recordingResponseWriter struct {
+	http.ResponseWriter
+	statusCode int
+	body       bytes.Buffer
+}
+
+// WriteHeader is part of http.ResponseWriter interface
+func (r *recordingResponseWriter) WriteHeader(status int) {
+	r.statusCode = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+// Write is part of http.ResponseWriter interface
+func (r *recordingResponseWriter) Write(data []byte) (int, error) {
+	r.body.Write(data)
+	return r.ResponseWriter.Write(data)
+}
+
+// Unwrap returns the original http.ResponseWriter, for use by http.ResponseController.
+func (r *recordingResponseWriter) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
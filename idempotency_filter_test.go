@@ -0,0 +1,123 @@
+package restful
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestIdempotencyFilter(t *testing.T) {
+	created := 0
+	c := NewContainer()
+	ws := new(WebService).Path("/orders")
+	ws.Filter(NewIdempotencyFilter(NewMemoryIdempotencyStore(), ""))
+	ws.Route(ws.POST("").To(func(req *Request, resp *Response) {
+		created++
+		resp.Header().Set("Location", fmt.Sprintf("/orders/%d", created))
+		resp.WriteHeader(http.StatusCreated)
+		io.WriteString(resp, fmt.Sprintf("order %d", created))
+	}))
+	c.Add(ws)
+
+	for _, each := range []struct {
+		key      string
+		body     string
+		location string
+	}{
+		{"a", "order 1", "/orders/1"},
+		{"a", "order 1", "/orders/1"}, // replayed
+		{"b", "order 2", "/orders/2"},
+		{"", "order 3", "/orders/3"}, // no key, not stored
+		{"", "order 4", "/orders/4"},
+	} {
+		httpRequest, _ := http.NewRequest("POST", "/orders", nil)
+		if len(each.key) > 0 {
+			httpRequest.Header.Set(HEADER_IdempotencyKey, each.key)
+		}
+		httpWriter := httptest.NewRecorder()
+		c.dispatch(httpWriter, httpRequest)
+		if got, want := httpWriter.Code, http.StatusCreated; got != want {
+			t.Errorf("[%s] got %v want %v", each.key, got, want)
+		}
+		if got, want := httpWriter.Body.String(), each.body; got != want {
+			t.Errorf("[%s] got %v want %v", each.key, got, want)
+		}
+		if got, want := httpWriter.Header().Get("Location"), each.location; got != want {
+			t.Errorf("[%s] got %v want %v", each.key, got, want)
+		}
+	}
+	if got, want := created, 4; got != want {
+		t.Errorf("got %v want %v orders created", got, want)
+	}
+}
+
+func TestIdempotencyFilterDifferentContent(t *testing.T) {
+	created := 0
+	c := NewContainer()
+	ws := new(WebService).Path("/orders")
+	ws.Filter(NewIdempotencyFilter(NewMemoryIdempotencyStore(), ""))
+	ws.Route(ws.POST("").To(func(req *Request, resp *Response) {
+		created++
+		resp.Header().Set("Location", fmt.Sprintf("/orders/%d", created))
+		resp.WriteHeader(http.StatusCreated)
+	}))
+	c.Add(ws)
+
+	post := func(content string) *httptest.ResponseRecorder {
+		httpRequest, _ := http.NewRequest("POST", "/orders", strings.NewReader(content))
+		httpRequest.Header.Set(HEADER_IdempotencyKey, "a")
+		httpWriter := httptest.NewRecorder()
+		c.dispatch(httpWriter, httpRequest)
+		return httpWriter
+	}
+	first := post(`{"item":"apple"}`)
+	// changing the replayed header must not change the stored response
+	first.Header()["Location"][0] = "/changed"
+	for _, each := range []struct {
+		content  string
+		code     int
+		location string
+	}{
+		{`{"item":"apple"}`, http.StatusCreated, "/orders/1"},
+		{`{"item":"apple"}`, http.StatusCreated, "/orders/1"},
+		{`{"item":"pear"}`, http.StatusUnprocessableEntity, ""},
+	} {
+		httpWriter := post(each.content)
+		if got, want := httpWriter.Code, each.code; got != want {
+			t.Errorf("[%s] got %v want %v", each.content, got, want)
+		}
+		if got, want := httpWriter.Header().Get("Location"), each.location; got != want {
+			t.Errorf("[%s] got %v want %v", each.content, got, want)
+		}
+		if len(each.location) > 0 {
+			httpWriter.Header()["Location"][0] = "/changed"
+		}
+	}
+	if got, want := created, 1; got != want {
+		t.Errorf("got %v want %v orders created", got, want)
+	}
+}
+
+func TestIdempotencyFilterFlush(t *testing.T) {
+	c := NewContainer()
+	ws := new(WebService).Path("/orders")
+	ws.Filter(NewIdempotencyFilter(NewMemoryIdempotencyStore(), ""))
+	ws.Route(ws.POST("").To(func(req *Request, resp *Response) {
+		io.WriteString(resp, "accepted")
+		if err := http.NewResponseController(resp.ResponseWriter).Flush(); err != nil {
+			t.Errorf("unexpected error %v", err)
+		}
+	}))
+	c.Add(ws)
+
+	httpRequest, _ := http.NewRequest("POST", "/orders", nil)
+	httpRequest.Header.Set(HEADER_IdempotencyKey, "a")
+	httpWriter := httptest.NewRecorder()
+	c.dispatch(httpWriter, httpRequest)
+	if !httpWriter.Flushed {
+		t.Error("response should be flushed")
+	}
+}
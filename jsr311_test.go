@@ -2,6 +2,7 @@ package restful
 
 import (
 	"io"
+	"net/http"
 	"sort"
 	"testing"
 )
@@ -209,4 +210,26 @@ func TestSortableRouteCandidates(t *testing.T) {
 	}
 }
 
+// go test -v -test.run TestLiteralRouteBeatsParameterRoute ...restful
+func TestLiteralRouteBeatsParameterRoute(t *testing.T) {
+	for _, literalFirst := range []bool{true, false} {
+		ws1 := new(WebService).Path("/users")
+		if literalFirst {
+			ws1.Route(ws1.GET("/me").To(dummy))
+			ws1.Route(ws1.GET("/{id}").To(dummy))
+		} else {
+			ws1.Route(ws1.GET("/{id}").To(dummy))
+			ws1.Route(ws1.GET("/me").To(dummy))
+		}
+		req, _ := http.NewRequest("GET", "/users/me", nil)
+		_, route, err := RouterJSR311{}.SelectRoute([]*WebService{ws1}, req)
+		if err != nil {
+			t.Fatalf("[%v] unexpected error %v", literalFirst, err)
+		}
+		if got, want := route.Path, "/users/me"; got != want {
+			t.Errorf("[%v] got route %s want %s", literalFirst, got, want)
+		}
+	}
+}
+
 func dummy(req *Request, resp *Response) { io.WriteString(resp.ResponseWriter, "dummy") }
@@ -36,6 +36,8 @@ type ParameterData struct {
 	AllowableValues                         map[string]string
 	AllowMultiple                           bool
 	DefaultValue                            string
+	Example                                 interface{}            // example value for documentation purposes
+	Examples                                map[string]interface{} // named example values for documentation purposes
 }
 
 // Data returns the state of the Parameter
@@ -48,6 +50,27 @@ func (p *Parameter) Kind() int {
 	return p.data.Kind
 }
 
+// parameterKindNames are the names of the parameter kinds, as used in e.g. Swagger documentation.
+var parameterKindNames = []string{"path", "query", "body", "header", "form"}
+
+// KindName returns the name of the parameter type indicator: path, query, body, header or form.
+func (p *Parameter) KindName() string {
+	if p.data.Kind < 0 || p.data.Kind >= len(parameterKindNames) {
+		return ""
+	}
+	return parameterKindNames[p.data.Kind]
+}
+
+// Name returns the name of the parameter. Use Data to read its other fields, such as Description and DataType.
+func (p *Parameter) Name() string {
+	return p.data.Name
+}
+
+// IsRequired returns whether the parameter is required.
+func (p *Parameter) IsRequired() bool {
+	return p.data.Required
+}
+
 func (p *Parameter) bePath() *Parameter {
 	p.data.Kind = PathParameterKind
 	return p
@@ -112,3 +135,18 @@ func (p *Parameter) Description(doc string) *Parameter {
 	p.data.Description = doc
 	return p
 }
+
+// Example sets the example value field for documentation and returns the receiver
+func (p *Parameter) Example(value interface{}) *Parameter {
+	p.data.Example = value
+	return p
+}
+
+// AddExample adds (or replaces) a named example value for documentation and returns the receiver
+func (p *Parameter) AddExample(name string, value interface{}) *Parameter {
+	if p.data.Examples == nil {
+		p.data.Examples = map[string]interface{}{}
+	}
+	p.data.Examples[name] = value
+	return p
+}
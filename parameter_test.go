@@ -0,0 +1,55 @@
+package restful
+
+import "testing"
+
+func TestParameterExample(t *testing.T) {
+	p := QueryParameter("limit", "max items").DataType("integer").Example(10)
+	if got, want := p.Data().Example, 10; got != want {
+		t.Errorf("got %v want %v", got, want)
+	}
+	if p.Data().Examples != nil {
+		t.Errorf("unexpected examples %v", p.Data().Examples)
+	}
+}
+
+func TestParameterMultipleExamples(t *testing.T) {
+	p := PathParameter("id", "identifier").AddExample("small", "1").AddExample("large", "999999")
+	examples := p.Data().Examples
+	if len(examples) != 2 {
+		t.Fatalf("got %d examples want 2", len(examples))
+	}
+	if got, want := examples["small"], "1"; got != want {
+		t.Errorf("got %v want %v", got, want)
+	}
+	if got, want := examples["large"], "999999"; got != want {
+		t.Errorf("got %v want %v", got, want)
+	}
+}
+
+func TestParameterKindIntrospection(t *testing.T) {
+	for _, each := range []struct {
+		param    *Parameter
+		kind     int
+		kindName string
+		required bool
+	}{
+		{PathParameter("id", ""), PathParameterKind, "path", true},
+		{QueryParameter("limit", ""), QueryParameterKind, "query", false},
+		{BodyParameter("order", ""), BodyParameterKind, "body", true},
+		{HeaderParameter("X-Trace", ""), HeaderParameterKind, "header", false},
+		{FormParameter("email", ""), FormParameterKind, "form", false},
+	} {
+		if got, want := each.param.Kind(), each.kind; got != want {
+			t.Errorf("[%s] got %v want %v", each.kindName, got, want)
+		}
+		if got, want := each.param.KindName(), each.kindName; got != want {
+			t.Errorf("got %v want %v", got, want)
+		}
+		if got, want := each.param.IsRequired(), each.required; got != want {
+			t.Errorf("[%s] got %v want %v", each.kindName, got, want)
+		}
+	}
+	if got, want := QueryParameter("limit", "max items").Name(), "limit"; got != want {
+		t.Errorf("got %v want %v", got, want)
+	}
+}
@@ -42,6 +42,12 @@ func templateToRegularExpression(template string) (expression string, literalCou
 		if each == "" {
 			continue
 		}
+		if isOptionalParameterToken(each) {
+			// the segment, including its slash, may be absent
+			buffer.WriteString("(?:/([^/]+?))?")
+			varCount += 1
+			continue
+		}
 		buffer.WriteString("/")
 		if strings.HasPrefix(each, "{") {
 			// check for regular expression in variable
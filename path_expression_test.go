@@ -12,6 +12,7 @@ var tempregexs = []struct {
 	{"/{p}/abcde", "^/([^/]+?)/abcde(/.*)?$", 5, 1},
 	{"/a/{b:*}", "^/a/(.*)(/.*)?$", 1, 1},
 	{"/a/{b:[a-z]+}", "^/a/([a-z]+)(/.*)?$", 1, 1},
+	{"/a/{b?}", "^/a(?:/([^/]+?))?(/.*)?$", 1, 1},
 }
 
 func TestTemplateToRegularExpression(t *testing.T) {
@@ -0,0 +1,115 @@
+package restful
+
+// Copyright 2026 agent. All rights reserved.
+// Use of this source code is governed by a license
+// that can be found in the LICENSE file.
+
+import (
+	"fmt"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	timeType     = reflect.TypeOf(time.Time{})
+	durationType = reflect.TypeOf(time.Duration(0))
+)
+
+// ReadQueryInto sets the fields of the struct, pointed to by v, that have a `query:"name"` tag
+// to the values of the Query parameters by that name. Fields of type string, bool, int, uint, float,
+// time.Duration and time.Time (use a `layout:"2006-01-02"` tag for a layout other than time.RFC3339) are supported,
+// as well as slices of these which get the values of a repeated parameter (e.g. ?tag=a&tag=b).
+// Fields of absent parameters are not changed. If a value cannot be converted then a ServiceError
+// with Http Status BadRequest (400) is returned that names the parameter and the field.
+func (r *Request) ReadQueryInto(v interface{}) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("ReadQueryInto requires a non-nil pointer to a struct, got %T", v)
+	}
+	query := r.Request.URL.Query()
+	target := rv.Elem()
+	for i := 0; i < target.NumField(); i++ {
+		field := target.Type().Field(i)
+		name := strings.Split(field.Tag.Get("query"), ",")[0]
+		if len(name) == 0 || name == "-" || len(field.PkgPath) > 0 { // untagged or unexported
+			continue
+		}
+		values, ok := query[name]
+		if !ok || len(values) == 0 {
+			continue
+		}
+		fieldValue := target.Field(i)
+		if fieldValue.Kind() == reflect.Slice && field.Type.Elem().Kind() != reflect.Uint8 {
+			slice := reflect.MakeSlice(field.Type, len(values), len(values))
+			for j, each := range values {
+				if err := setQueryValue(slice.Index(j), field, name, each); err != nil {
+					return err
+				}
+			}
+			fieldValue.Set(slice)
+			continue
+		}
+		if err := setQueryValue(fieldValue, field, name, values[0]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// setQueryValue converts the value of the Query parameter to the type of the (element of the) field and sets it.
+func setQueryValue(target reflect.Value, field reflect.StructField, name, value string) error {
+	invalid := func(kind string) error {
+		return NewError(http.StatusBadRequest,
+			fmt.Sprintf("400: Invalid value for query parameter %s (field %s): %q is not a valid %s", name, field.Name, value, kind))
+	}
+	if target.Type() == timeType {
+		parsed, err := parseTimeParameter("query", name, value, field.Tag.Get("layout"))
+		if err != nil {
+			return invalid("time")
+		}
+		target.Set(reflect.ValueOf(parsed))
+		return nil
+	}
+	switch target.Kind() {
+	case reflect.String:
+		target.SetString(value)
+	case reflect.Bool:
+		parsed, err := strconv.ParseBool(value)
+		if err != nil {
+			return invalid("bool")
+		}
+		target.SetBool(parsed)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if target.Type() == durationType {
+			parsed, err := time.ParseDuration(value)
+			if err != nil {
+				return invalid("duration")
+			}
+			target.SetInt(int64(parsed))
+			return nil
+		}
+		parsed, err := strconv.ParseInt(value, 10, target.Type().Bits())
+		if err != nil {
+			return invalid("integer")
+		}
+		target.SetInt(parsed)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		parsed, err := strconv.ParseUint(value, 10, target.Type().Bits())
+		if err != nil {
+			return invalid("unsigned integer")
+		}
+		target.SetUint(parsed)
+	case reflect.Float32, reflect.Float64:
+		parsed, err := strconv.ParseFloat(value, target.Type().Bits())
+		if err != nil {
+			return invalid("number")
+		}
+		target.SetFloat(parsed)
+	default:
+		return fmt.Errorf("unsupported type %s of field %s for query parameter %s", target.Type(), field.Name, name)
+	}
+	return nil
+}
@@ -0,0 +1,71 @@
+package restful
+
+import (
+	"net/http"
+	"net/url"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type listQuery struct {
+	Page    int           `query:"page"`
+	Size    uint8         `query:"size"`
+	Verbose bool          `query:"verbose"`
+	Ratio   float64       `query:"ratio"`
+	Since   time.Time     `query:"since"`
+	Day     time.Time     `query:"day" layout:"2006-01-02"`
+	Timeout time.Duration `query:"timeout"`
+	Tags    []string      `query:"tag"`
+	Ids     []int         `query:"id"`
+	Sort    string        `query:"sort"`
+	Ignored string
+}
+
+func TestReadQueryInto(t *testing.T) {
+	hreq := http.Request{Method: "GET"}
+	hreq.URL, _ = url.Parse("http://here.com/items?page=2&size=50&verbose=true&ratio=0.5" +
+		"&since=2026-03-01T10:00:00Z&day=2026-03-02&timeout=1m30s&tag=a&tag=b&id=1&id=2&id=3&Ignored=x")
+	rreq := Request{Request: &hreq}
+	q := listQuery{Sort: "name"}
+	if err := rreq.ReadQueryInto(&q); err != nil {
+		t.Fatal(err)
+	}
+	want := listQuery{
+		Page:    2,
+		Size:    50,
+		Verbose: true,
+		Ratio:   0.5,
+		Since:   time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC),
+		Day:     time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC),
+		Timeout: 90 * time.Second,
+		Tags:    []string{"a", "b"},
+		Ids:     []int{1, 2, 3},
+		Sort:    "name",
+	}
+	if !reflect.DeepEqual(q, want) {
+		t.Errorf("got %+v want %+v", q, want)
+	}
+}
+
+func TestReadQueryIntoInvalid(t *testing.T) {
+	for query, want := range map[string]string{
+		"page=two":   `400: Invalid value for query parameter page (field Page): "two" is not a valid integer`,
+		"size=300":   `400: Invalid value for query parameter size (field Size): "300" is not a valid unsigned integer`,
+		"id=1&id=x":  `400: Invalid value for query parameter id (field Ids): "x" is not a valid integer`,
+		"day=2/3/26": `400: Invalid value for query parameter day (field Day): "2/3/26" is not a valid time`,
+	} {
+		hreq := http.Request{Method: "GET"}
+		hreq.URL, _ = url.Parse("http://here.com/items?" + query)
+		rreq := Request{Request: &hreq}
+		err := rreq.ReadQueryInto(new(listQuery))
+		if got, want := err, NewError(http.StatusBadRequest, want); !reflect.DeepEqual(got, want) {
+			t.Errorf("[%s] got %v want %v", query, got, want)
+		}
+	}
+	hreq := http.Request{Method: "GET"}
+	hreq.URL, _ = url.Parse("http://here.com/items")
+	if err := (&Request{Request: &hreq}).ReadQueryInto(listQuery{}); err == nil {
+		t.Error("expected error for non-pointer")
+	}
+}
@@ -0,0 +1,57 @@
+package restful
+
+// Copyright 2026 agent. All rights reserved.
+// Use of this source code is governed by a license
+// that can be found in the LICENSE file.
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+)
+
+// NewQueryTypeCoercionFilter returns a filter that checks the values of the query parameters, documented for the selected Route,
+// that have a numeric DataType such as "integer" or "number". If a value cannot be parsed as such then
+// Http status BadRequest (400) is written with a message that names the parameter.
+// Absent parameters are not checked, unless documented as Required, which results in a BadRequest (400) too.
+func NewQueryTypeCoercionFilter() FilterFunction {
+	return func(req *Request, resp *Response, chain *FilterChain) {
+		if req.selectedRoute != nil {
+			query := req.Request.URL.Query()
+			for _, each := range req.selectedRoute.ParameterDocs {
+				data := each.Data()
+				if data.Kind != QueryParameterKind {
+					continue
+				}
+				if _, present := query[data.Name]; !present && data.Required {
+					resp.WriteErrorString(http.StatusBadRequest, "400: Missing required query parameter "+data.Name)
+					return
+				}
+				for _, value := range query[data.Name] {
+					if len(value) > 0 && !isValidNumber(data.DataType, value) {
+						resp.WriteErrorString(http.StatusBadRequest,
+							fmt.Sprintf("400: Invalid value for query parameter %s: %q is not a valid %s", data.Name, value, data.DataType))
+						return
+					}
+				}
+			}
+		}
+		chain.ProcessFilter(req, resp)
+	}
+}
+
+// isValidNumber returns whether the value can be parsed as the dataType ; non-numeric types are always valid.
+func isValidNumber(dataType, value string) bool {
+	var err error
+	switch dataType {
+	case "integer", "int", "int64", "long":
+		_, err = strconv.ParseInt(value, 10, 64)
+	case "int32":
+		_, err = strconv.ParseInt(value, 10, 32)
+	case "number", "float", "float64", "double":
+		_, err = strconv.ParseFloat(value, 64)
+	case "float32":
+		_, err = strconv.ParseFloat(value, 32)
+	}
+	return err == nil
+}
@@ -0,0 +1,63 @@
+package restful
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestQueryTypeCoercionFilter(t *testing.T) {
+	c := NewContainer()
+	ws := new(WebService).Path("/items")
+	ws.Filter(NewQueryTypeCoercionFilter())
+	ws.Route(ws.GET("").
+		Param(ws.QueryParameter("limit", "maximum number of items").DataType("integer")).
+		Param(ws.QueryParameter("ratio", "minimum ratio").DataType("number")).
+		Param(ws.QueryParameter("name", "name of the item")).
+		To(doNothing))
+	c.Add(ws)
+	for _, each := range []struct {
+		query string
+		code  int
+		body  string
+	}{
+		{"limit=10&ratio=0.5&name=x", http.StatusOK, ""},
+		{"", http.StatusOK, ""},
+		{"limit=ten", http.StatusBadRequest, `400: Invalid value for query parameter limit: "ten" is not a valid integer`},
+		{"limit=1&limit=2.5", http.StatusBadRequest, `400: Invalid value for query parameter limit: "2.5" is not a valid integer`},
+		{"ratio=half", http.StatusBadRequest, `400: Invalid value for query parameter ratio: "half" is not a valid number`},
+	} {
+		httpRequest, _ := http.NewRequest("GET", "/items?"+each.query, nil)
+		httpWriter := httptest.NewRecorder()
+		c.dispatch(httpWriter, httpRequest)
+		if got, want := httpWriter.Code, each.code; got != want {
+			t.Errorf("[%s] got %v want %v", each.query, got, want)
+		}
+		if got, want := httpWriter.Body.String(), each.body; got != want {
+			t.Errorf("[%s] got %v want %v", each.query, got, want)
+		}
+	}
+}
+
+func TestQueryTypeCoercionFilterRequired(t *testing.T) {
+	limit := QueryParameter("limit", "maximum number of items").DataType("integer")
+	if limit.IsRequired() || !limit.Required(true).IsRequired() || !limit.Data().Required {
+		t.Fatal("query parameter must be optional by default and required after flipping")
+	}
+	c := NewContainer()
+	ws := new(WebService).Path("/items")
+	ws.Filter(NewQueryTypeCoercionFilter())
+	ws.Route(ws.GET("").Param(limit).To(doNothing))
+	c.Add(ws)
+	for query, want := range map[string]int{"limit=1": http.StatusOK, "limit=": http.StatusOK, "": http.StatusBadRequest} {
+		httpRequest, _ := http.NewRequest("GET", "/items?"+query, nil)
+		httpWriter := httptest.NewRecorder()
+		c.dispatch(httpWriter, httpRequest)
+		if got := httpWriter.Code; got != want {
+			t.Errorf("[%s] got %v want %v", query, got, want)
+		}
+		if want == http.StatusBadRequest && httpWriter.Body.String() != "400: Missing required query parameter limit" {
+			t.Errorf("[%s] unexpected body %s", query, httpWriter.Body.String())
+		}
+	}
+}
@@ -7,21 +7,40 @@ package restful
 import (
 	"bytes"
 	"compress/zlib"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
 	"io/ioutil"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
 )
 
 var defaultRequestContentType string
 
 var doCacheReadEntityBytes = true
 
+var doAllowEmptyEntityBody = false
+
+// ErrEmptyBody is returned by ReadEntity if the request has no content (or only whitespace) to unmarshal.
+var ErrEmptyBody = errors.New("empty request body")
+
 // Request is a wrapper for a http Request that provides convenience methods
 type Request struct {
 	Request           *http.Request
 	bodyContent       *[]byte // to cache the request body for multiple reads of ReadEntity
+	bodyDecompressed  bool    // true if the request body is already wrapped by a decompressing reader
 	pathParameters    map[string]string
 	attributes        map[string]interface{} // for storing request-scoped values
 	selectedRoutePath string                 // root path + route path that matched the request, e.g. /meetings/{id}/attendees
+	routeConsumes     []string               // MIME types the selected route can consume ; first one is used if Content-Type is missing
+	bodyCounter       *countingReadCloser    // counts the bytes read from the request body, if installed when dispatching
+	contentType       string                 // if set then ReadEntity uses this MIME type instead of the Content-Type Header
+	serviceAccessors  *entityReaderWriters   // accessors registered for the WebService ; consulted before the global ones
+	selectedRoute     *Route                 // the Route that matched the request, if any
+	consumesDefault   string                 // MIME type set by WebService.SetConsumesDefault, if any
 }
 
 func NewRequest(httpRequest *http.Request) *Request {
@@ -47,6 +66,13 @@ func SetCacheReadEntity(doCache bool) {
 	doCacheReadEntityBytes = doCache
 }
 
+// SetAllowEmptyEntityBody controls whether ReadEntity accepts a request without content.
+// If true then the entityPointer is left untouched and no error is returned.
+// Default is false ; ReadEntity returns ErrEmptyBody.
+func SetAllowEmptyEntityBody(allow bool) {
+	doAllowEmptyEntityBody = allow
+}
+
 // PathParameter accesses the Path parameter value by its name
 func (r *Request) PathParameter(name string) string {
 	return r.pathParameters[name]
@@ -62,6 +88,40 @@ func (r *Request) QueryParameter(name string) string {
 	return r.Request.FormValue(name)
 }
 
+// QueryTimeParameter parses the (first) Query parameter value by its name as a time using the layout,
+// or time.RFC3339 if layout is empty. It returns the zero time if the parameter is absent or empty.
+// If the value cannot be parsed then a ServiceError with Http Status BadRequest (400) is returned.
+func (r *Request) QueryTimeParameter(name, layout string) (time.Time, error) {
+	return parseTimeParameter("query", name, r.QueryParameter(name), layout)
+}
+
+// PathTimeParameter is like QueryTimeParameter but parses the value of the Path parameter by its name.
+func (r *Request) PathTimeParameter(name, layout string) (time.Time, error) {
+	return parseTimeParameter("path", name, r.PathParameter(name), layout)
+}
+
+// parseTimeParameter parses the value of a parameter of a kind (path,query) as time.
+func parseTimeParameter(kind, name, value, layout string) (time.Time, error) {
+	if len(value) == 0 {
+		return time.Time{}, nil
+	}
+	if len(layout) == 0 {
+		layout = time.RFC3339
+	}
+	parsed, err := time.Parse(layout, value)
+	if err != nil {
+		return time.Time{}, NewError(http.StatusBadRequest,
+			fmt.Sprintf("400: Invalid value for %s parameter %s: %q does not match the time layout %q", kind, name, value, layout))
+	}
+	return parsed, nil
+}
+
+// QueryParameterExists returns whether the URL has a Query parameter by its name, even without a value (e.g. ?verbose).
+func (r *Request) QueryParameterExists(name string) bool {
+	_, ok := r.Request.URL.Query()[name]
+	return ok
+}
+
 // BodyParameter parses the body of the request (once for typically a POST or a PUT) and returns the value of the given name or an error.
 func (r *Request) BodyParameter(name string) (string, error) {
 	err := r.Request.ParseForm()
@@ -71,15 +131,92 @@ func (r *Request) BodyParameter(name string) (string, error) {
 	return r.Request.PostFormValue(name), nil
 }
 
-// HeaderParameter returns the HTTP Header value of a Header name or empty if missing
+// HeaderParameter returns the HTTP Header value of a Header name or empty if missing.
+// The name is case-insensitive ; it is canonicalized using textproto.CanonicalMIMEHeaderKey.
 func (r *Request) HeaderParameter(name string) string {
 	return r.Request.Header.Get(name)
 }
 
+// HeaderIntParameter parses the HTTP Header value of a Header name (e.g. X-Page-Size) as an int.
+// It returns def if the Header is missing or empty.
+// If the value is not an integer then def and a ServiceError with Http Status BadRequest (400) are returned.
+func (r *Request) HeaderIntParameter(name string, def int) (int, error) {
+	value := strings.TrimSpace(r.HeaderParameter(name))
+	if len(value) == 0 {
+		return def, nil
+	}
+	parsed, err := strconv.Atoi(value)
+	if err != nil {
+		return def, NewError(http.StatusBadRequest,
+			fmt.Sprintf("400: Invalid value for header parameter %s: %q is not an integer", name, value))
+	}
+	return parsed, nil
+}
+
+// IsWebSocketUpgrade returns whether the request asks to upgrade the connection to the WebSocket protocol (RFC 6455).
+// Use Response.Hijack to take over the connection for such a request.
+func (r *Request) IsWebSocketUpgrade() bool {
+	if r.Request.Method != "GET" || !strings.EqualFold(strings.TrimSpace(r.Request.Header.Get("Upgrade")), "websocket") {
+		return false
+	}
+	for _, each := range r.Request.Header["Connection"] {
+		for _, token := range strings.Split(each, ",") {
+			if strings.EqualFold(strings.TrimSpace(token), "upgrade") {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// IfMatch returns the entity tags (including quotes) listed by the If-Match Header, or empty if missing.
+func (r *Request) IfMatch() []string {
+	tags := []string{}
+	for _, each := range strings.Split(r.Request.Header.Get(HEADER_IfMatch), ",") {
+		if tag := strings.TrimSpace(each); len(tag) > 0 {
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
+
+// MatchesIfMatch returns whether the current entity tag of the resource (e.g. "v2", including quotes)
+// satisfies the If-Match precondition of the request. It is satisfied if the Header is missing,
+// if it is "*" or if it lists the etag. Weak entity tags (W/"v2") never match.
+func (r *Request) MatchesIfMatch(etag string) bool {
+	tags := r.IfMatch()
+	if len(tags) == 0 {
+		return true
+	}
+	for _, each := range tags {
+		if each == "*" || (each == etag && !strings.HasPrefix(each, "W/")) {
+			return true
+		}
+	}
+	return false
+}
+
+// BodyBytes reads (once) and returns the raw content of the request body.
+// The body is replaced by a reader on the same content such that ReadEntity can still be used.
+func (r *Request) BodyBytes() ([]byte, error) {
+	if r.bodyContent == nil {
+		data, err := ioutil.ReadAll(r.Request.Body)
+		if err != nil {
+			return nil, err
+		}
+		r.bodyContent = &data
+	}
+	r.Request.Body = ioutil.NopCloser(bytes.NewReader(*r.bodyContent))
+	return *r.bodyContent, nil
+}
+
 // ReadEntity checks the Accept header and reads the content into the entityPointer.
+// Returns ErrEmptyBody if the request has no content, unless SetAllowEmptyEntityBody(true) was called.
 func (r *Request) ReadEntity(entityPointer interface{}) (err error) {
 	contentType := r.Request.Header.Get(HEADER_ContentType)
-	contentEncoding := r.Request.Header.Get(HEADER_ContentEncoding)
+	if len(r.contentType) > 0 {
+		contentType = r.contentType
+	}
 
 	// OLD feature, cache the body for reads
 	if doCacheReadEntityBytes {
@@ -93,30 +230,120 @@ func (r *Request) ReadEntity(entityPointer interface{}) (err error) {
 		r.Request.Body = ioutil.NopCloser(bytes.NewReader(*r.bodyContent))
 	}
 
-	// check if the request body needs decompression
-	if ENCODING_GZIP == contentEncoding {
-		gzipReader := currentCompressorProvider.AcquireGzipReader()
-		defer currentCompressorProvider.ReleaseGzipReader(gzipReader)
-		gzipReader.Reset(r.Request.Body)
-		r.Request.Body = gzipReader
-	} else if ENCODING_DEFLATE == contentEncoding {
-		zlibReader, err := zlib.NewReader(r.Request.Body)
-		if err != nil {
-			return err
+	// check if the request body needs decompression ; unless done by the reader installed when dispatching
+	if !r.bodyDecompressed {
+		codings := requestContentCodings(r.Request.Header)
+		for ix := len(codings) - 1; ix >= 0; ix-- {
+			if ENCODING_GZIP == codings[ix] {
+				gzipReader := currentCompressorProvider.AcquireGzipReader()
+				defer currentCompressorProvider.ReleaseGzipReader(gzipReader)
+				gzipReader.Reset(r.Request.Body)
+				r.Request.Body = gzipReader
+			} else if ENCODING_DEFLATE == codings[ix] {
+				zlibReader, err := zlib.NewReader(r.Request.Body)
+				if err != nil {
+					return err
+				}
+				r.Request.Body = zlibReader
+			}
 		}
-		r.Request.Body = zlibReader
+	}
+
+	if len(contentType) == 0 {
+		contentType = r.defaultContentType()
 	}
 
 	// lookup the EntityReader
-	entityReader, ok := entityAccessRegistry.AccessorAt(contentType)
+	entityReader, ok := accessorAt(r.serviceAccessors, contentType)
 	if !ok {
 		return NewError(http.StatusBadRequest, "Unable to unmarshal content of type:"+contentType)
 	}
 	return entityReader.Read(r, entityPointer)
 }
 
+// ReadEntities reads newline delimited JSON values (see MIME_NDJSON) from the body, one at a time.
+// For each value, factory must return a new pointer to unmarshal into, which is then passed to each.
+// It stops at the end of the body or when each returns an error, which is then returned.
+// Unlike ReadEntity, the body is not cached such that large uploads can be processed.
+func (r *Request) ReadEntities(factory func() interface{}, each func(interface{}) error) error {
+	decoder := json.NewDecoder(withoutBOM(r.Request.Body))
+	if doUseJSONNumber {
+		decoder.UseNumber()
+	}
+	for {
+		entityPointer := factory()
+		if err := decoder.Decode(entityPointer); err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		}
+		if err := each(entityPointer); err != nil {
+			return err
+		}
+	}
+}
+
+// ReadJSONArrayStream reads the elements of a top-level JSON array from the body, one at a time.
+// For each element, factory must return a new pointer to unmarshal into, which is then passed to each.
+// It stops at the end of the array or when each returns an error, which is then returned.
+// Like ReadEntities, the body is not cached such that large uploads can be processed.
+// Returns a ServiceError with Http Status BadRequest (400) if the content is not an array
+// and a SyntaxError if it is malformed.
+func (r *Request) ReadJSONArrayStream(factory func() interface{}, each func(interface{}) error) error {
+	decoder := json.NewDecoder(withoutBOM(r.Request.Body))
+	if doUseJSONNumber {
+		decoder.UseNumber()
+	}
+	arrayToken := func(want json.Delim) error {
+		token, err := decoder.Token()
+		if err != nil {
+			return jsonDecodeError(decoder, err)
+		}
+		if token != want {
+			return NewError(http.StatusBadRequest, fmt.Sprintf("400: Invalid JSON array, expected %v at offset %d", want, decoder.InputOffset()))
+		}
+		return nil
+	}
+	if err := arrayToken('['); err != nil {
+		return err
+	}
+	for decoder.More() {
+		entityPointer := factory()
+		if err := decoder.Decode(entityPointer); err != nil {
+			return jsonDecodeError(decoder, err)
+		}
+		if err := each(entityPointer); err != nil {
+			return err
+		}
+	}
+	return arrayToken(']')
+}
+
+// SetContentType overrides the Content-Type Header for selecting the EntityReader in ReadEntity.
+// Use it for clients that cannot set that Header correctly, such as a HTML form posting JSON.
+func (r *Request) SetContentType(mime string) {
+	r.contentType = mime
+}
+
+// defaultContentType returns the MIME type to read an entity with if the Content-Type Header is missing.
+// This is the one set for the WebService, the first type the route consumes or else the one set by DefaultRequestContentType.
+func (r *Request) defaultContentType() string {
+	if len(r.consumesDefault) > 0 {
+		return r.consumesDefault
+	}
+	if len(r.routeConsumes) > 0 && !strings.Contains(r.routeConsumes[0], "*") {
+		return r.routeConsumes[0]
+	}
+	return defaultRequestContentType
+}
+
 // SetAttribute adds or replaces the attribute with the given value.
 func (r *Request) SetAttribute(name string, value interface{}) {
+	if r.attributes == nil {
+		// lazy init because a Request may not be created using NewRequest
+		r.attributes = map[string]interface{}{}
+	}
 	r.attributes[name] = value
 }
 
@@ -125,7 +352,37 @@ func (r Request) Attribute(name string) interface{} {
 	return r.attributes[name]
 }
 
+// GetAttribute returns the attribute value associated to the given name as a T.
+// Returns the zero value of T and false if the attribute is absent or of another type.
+//
+//	user, ok := restful.GetAttribute[*User](req, "user")
+func GetAttribute[T any](r *Request, name string) (T, bool) {
+	value, ok := r.attributes[name].(T)
+	return value, ok
+}
+
 // SelectedRoutePath root path + route path that matched the request, e.g. /meetings/{id}/attendees
 func (r Request) SelectedRoutePath() string {
 	return r.selectedRoutePath
 }
+
+// BodyBytesRead returns the number of bytes read so far from the (possibly compressed) request body.
+// This value is only available for requests dispatched to a Route, e.g. for a RequestObserverFunction.
+func (r Request) BodyBytesRead() int {
+	if r.bodyCounter == nil {
+		return 0
+	}
+	return r.bodyCounter.count
+}
+
+// countingReadCloser counts the bytes read from the ReadCloser it wraps.
+type countingReadCloser struct {
+	io.ReadCloser
+	count int
+}
+
+func (c *countingReadCloser) Read(p []byte) (int, error) {
+	read, err := c.ReadCloser.Read(p)
+	c.count += read
+	return read, err
+}
@@ -2,11 +2,16 @@ package restful
 
 import (
 	"encoding/json"
+	"errors"
+	"io"
 	"net/http"
+	"net/http/httptest"
 	"net/url"
+	"reflect"
 	"strconv"
 	"strings"
 	"testing"
+	"time"
 )
 
 func TestQueryParameter(t *testing.T) {
@@ -18,6 +23,70 @@ func TestQueryParameter(t *testing.T) {
 	}
 }
 
+func TestQueryParameterExists(t *testing.T) {
+	hreq := http.Request{Method: "GET"}
+	hreq.URL, _ = url.Parse("http://www.google.com/search?verbose&q=foo&empty=")
+	rreq := Request{Request: &hreq}
+	for name, want := range map[string]bool{"verbose": true, "empty": true, "q": true, "missing": false} {
+		if got := rreq.QueryParameterExists(name); got != want {
+			t.Errorf("[%s] got %v want %v", name, got, want)
+		}
+	}
+}
+
+func TestHeaderIntParameter(t *testing.T) {
+	hreq := http.Request{Method: "GET", Header: http.Header{}}
+	hreq.Header.Set("X-Page-Size", " 25")
+	hreq.Header.Set("X-Page", "two")
+	rreq := Request{Request: &hreq}
+	if got, want := rreq.HeaderParameter("x-page-size"), " 25"; got != want {
+		t.Errorf("got %q want %q", got, want)
+	}
+	for _, each := range []struct {
+		name string
+		want int
+		err  error
+	}{
+		{"x-PAGE-size", 25, nil},
+		{"X-Offset", 10, nil},
+		{"X-Page", 10, NewError(http.StatusBadRequest, `400: Invalid value for header parameter X-Page: "two" is not an integer`)},
+	} {
+		got, err := rreq.HeaderIntParameter(each.name, 10)
+		if got != each.want {
+			t.Errorf("[%s] got %v want %v", each.name, got, each.want)
+		}
+		if !reflect.DeepEqual(err, each.err) {
+			t.Errorf("[%s] got %v want %v", each.name, err, each.err)
+		}
+	}
+}
+
+func TestQueryTimeParameter(t *testing.T) {
+	hreq := http.Request{Method: "GET"}
+	hreq.URL, _ = url.Parse("http://www.google.com/search?from=2026-03-01T10:00:00Z&day=01-03-2026&to=yesterday")
+	rreq := Request{Request: &hreq, pathParameters: map[string]string{"day": "2026-03-02T00:00:00+01:00"}}
+
+	from, err := rreq.QueryTimeParameter("from", "")
+	if err != nil || !from.Equal(time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)) {
+		t.Errorf("got %v,%v want 2026-03-01 10:00 UTC", from, err)
+	}
+	day, err := rreq.QueryTimeParameter("day", "02-01-2006")
+	if err != nil || !day.Equal(time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("got %v,%v want 2026-03-01 UTC", day, err)
+	}
+	pathDay, err := rreq.PathTimeParameter("day", time.RFC3339)
+	if err != nil || !pathDay.Equal(time.Date(2026, 3, 1, 23, 0, 0, 0, time.UTC)) {
+		t.Errorf("got %v,%v want 2026-03-01 23:00 UTC", pathDay, err)
+	}
+	if missing, err := rreq.QueryTimeParameter("since", ""); err != nil || !missing.IsZero() {
+		t.Errorf("got %v,%v want zero time", missing, err)
+	}
+	_, err = rreq.QueryTimeParameter("to", "")
+	if got, want := err, NewError(http.StatusBadRequest, `400: Invalid value for query parameter to: "yesterday" does not match the time layout "2006-01-02T15:04:05Z07:00"`); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v want %v", got, want)
+	}
+}
+
 type Anything map[string]interface{}
 
 type Number struct {
@@ -202,3 +271,287 @@ func TestSetAttribute(t *testing.T) {
 		t.Fatalf("missing request attribute:%v", there)
 	}
 }
+
+func TestGetAttribute(t *testing.T) {
+	request := new(Request)
+	request.SetAttribute("count", 42)
+	if got, ok := GetAttribute[int](request, "count"); !ok || got != 42 {
+		t.Errorf("got %v,%v want 42,true", got, ok)
+	}
+	if got, ok := GetAttribute[string](request, "count"); ok || got != "" {
+		t.Errorf("got %q,%v want \"\",false", got, ok)
+	}
+	if got, ok := GetAttribute[*Sample](request, "missing"); ok || got != nil {
+		t.Errorf("got %v,%v want nil,false", got, ok)
+	}
+}
+
+func TestReadEntityEmptyBody(t *testing.T) {
+	for _, each := range []struct {
+		contentType, body string
+	}{
+		{MIME_JSON, ""},
+		{MIME_JSON, " \n\t "},
+		{MIME_XML, ""},
+		{MIME_XML, " \n\t "},
+	} {
+		httpRequest, _ := http.NewRequest("POST", "/test", strings.NewReader(each.body))
+		httpRequest.Header.Set("Content-Type", each.contentType)
+		request := NewRequest(httpRequest)
+		sam := new(Sample)
+		if got, want := request.ReadEntity(sam), ErrEmptyBody; got != want {
+			t.Errorf("[%s:%q] got %v want %v", each.contentType, each.body, got, want)
+		}
+	}
+}
+
+func TestReadEntityEmptyBodyAllowed(t *testing.T) {
+	SetAllowEmptyEntityBody(true)
+	defer SetAllowEmptyEntityBody(false)
+	for _, each := range []string{"", "   "} {
+		httpRequest, _ := http.NewRequest("POST", "/test", strings.NewReader(each))
+		httpRequest.Header.Set("Content-Type", MIME_JSON)
+		request := NewRequest(httpRequest)
+		sam := new(Sample)
+		if err := request.ReadEntity(sam); err != nil {
+			t.Errorf("[%q] unexpected error %v", each, err)
+		}
+		if sam.Value != "" {
+			t.Errorf("[%q] expected zero value, got %v", each, sam.Value)
+		}
+	}
+}
+
+func TestBodyBytesThenReadEntity(t *testing.T) {
+	for _, doCache := range []bool{true, false} {
+		SetCacheReadEntity(doCache)
+		c := NewContainer()
+		ws := new(WebService).Path("/samples")
+		var raw []byte
+		ws.Filter(func(req *Request, resp *Response, chain *FilterChain) {
+			data, err := req.BodyBytes()
+			if err != nil {
+				resp.WriteError(http.StatusBadRequest, err)
+				return
+			}
+			raw = data
+			chain.ProcessFilter(req, resp)
+		})
+		sam := new(Sample)
+		ws.Route(ws.POST("").To(func(req *Request, resp *Response) {
+			if err := req.ReadEntity(sam); err != nil {
+				resp.WriteError(http.StatusBadRequest, err)
+			}
+		}))
+		c.Add(ws)
+
+		httpRequest, _ := http.NewRequest("POST", "/samples", strings.NewReader(`{"Value":"42"}`))
+		httpRequest.Header.Set("Content-Type", MIME_JSON)
+		httpWriter := httptest.NewRecorder()
+		c.dispatch(httpWriter, httpRequest)
+		if got, want := string(raw), `{"Value":"42"}`; got != want {
+			t.Errorf("[cache:%v] got %v want %v", doCache, got, want)
+		}
+		if got, want := sam.Value, "42"; got != want {
+			t.Errorf("[cache:%v] got %v want %v", doCache, got, want)
+		}
+	}
+	SetCacheReadEntity(true)
+}
+
+func TestAttributeWithoutNewRequest(t *testing.T) {
+	httpRequest, _ := http.NewRequest("GET", "/test", nil)
+	request := &Request{Request: httpRequest}
+	if got := request.Attribute("missing"); got != nil {
+		t.Errorf("got %v want nil", got)
+	}
+	request.SetAttribute("go", "there")
+	if got, want := request.Attribute("go"), "there"; got != want {
+		t.Errorf("got %v want %v", got, want)
+	}
+}
+
+func TestAttributeFromFilterToFunction(t *testing.T) {
+	c := NewContainer()
+	ws := new(WebService).Path("/attributes")
+	ws.Filter(func(req *Request, resp *Response, chain *FilterChain) {
+		req.SetAttribute("user", "alice")
+		chain.ProcessFilter(req, resp)
+	})
+	var user interface{}
+	ws.Route(ws.GET("").To(func(req *Request, resp *Response) {
+		user = req.Attribute("user")
+	}))
+	c.Add(ws)
+	httpRequest, _ := http.NewRequest("GET", "/attributes", nil)
+	c.dispatch(httptest.NewRecorder(), httpRequest)
+	if got, want := user, "alice"; got != want {
+		t.Errorf("got %v want %v", got, want)
+	}
+}
+
+func TestMatchesIfMatch(t *testing.T) {
+	for _, each := range []struct {
+		ifMatch string
+		matches bool
+	}{
+		{"", true},
+		{`"v2"`, true},
+		{`"v1", "v2"`, true},
+		{`*`, true},
+		{`"v1"`, false},
+		{`W/"v2"`, false},
+	} {
+		httpRequest, _ := http.NewRequest("PUT", "/test", nil)
+		if len(each.ifMatch) > 0 {
+			httpRequest.Header.Set("If-Match", each.ifMatch)
+		}
+		if got, want := NewRequest(httpRequest).MatchesIfMatch(`"v2"`), each.matches; got != want {
+			t.Errorf("[%s] got %v want %v", each.ifMatch, got, want)
+		}
+	}
+}
+
+func TestReadEntityWithoutContentTypeUsesRouteConsumes(t *testing.T) {
+	c := NewContainer()
+	ws := new(WebService).Path("/search")
+	var readErr error
+	sam := new(Sample)
+	ws.Route(ws.GET("").Consumes(MIME_JSON, MIME_XML).To(func(req *Request, resp *Response) {
+		readErr = req.ReadEntity(sam)
+	}))
+	c.Add(ws)
+	httpRequest, _ := http.NewRequest("GET", "/search", strings.NewReader(`{"Value":"42"}`))
+	c.dispatch(httptest.NewRecorder(), httpRequest)
+	if readErr != nil {
+		t.Fatalf("got %v want no error", readErr)
+	}
+	if got, want := sam.Value, "42"; got != want {
+		t.Errorf("got %v want %v", got, want)
+	}
+}
+
+func TestReadEntityWithContentTypeOverride(t *testing.T) {
+	httpRequest, _ := http.NewRequest("POST", "/test", strings.NewReader(`{"Value":"42"}`))
+	httpRequest.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	request := NewRequest(httpRequest)
+	request.SetContentType(MIME_JSON)
+	sam := new(Sample)
+	if err := request.ReadEntity(sam); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := sam.Value, "42"; got != want {
+		t.Errorf("got %v want %v", got, want)
+	}
+}
+
+func TestIsWebSocketUpgrade(t *testing.T) {
+	for _, each := range []struct {
+		method, connection, upgrade string
+		want                        bool
+	}{
+		{"GET", "Upgrade", "websocket", true},
+		{"GET", "keep-alive, upgrade", "WebSocket", true},
+		{"GET", "keep-alive", "", false},
+		{"GET", "Upgrade", "h2c", false},
+		{"POST", "Upgrade", "websocket", false},
+	} {
+		httpRequest, _ := http.NewRequest(each.method, "/chat", nil)
+		httpRequest.Header.Set("Connection", each.connection)
+		if len(each.upgrade) > 0 {
+			httpRequest.Header.Set("Upgrade", each.upgrade)
+		}
+		if got := NewRequest(httpRequest).IsWebSocketUpgrade(); got != each.want {
+			t.Errorf("[%v] got %v want %v", each, got, each.want)
+		}
+	}
+}
+
+func TestReadEntitiesNDJSON(t *testing.T) {
+	body := "{\"Value\":\"1\"}\n{\"Value\":\"2\"}\n\n{\"Value\":\"3\"}\n"
+	for _, each := range []string{body, "\xEF\xBB\xBF" + body} {
+		httpRequest, _ := http.NewRequest("POST", "/ingest", strings.NewReader(each))
+		httpRequest.Header.Set("Content-Type", MIME_NDJSON)
+		values := []string{}
+		err := NewRequest(httpRequest).ReadEntities(
+			func() interface{} { return new(Sample) },
+			func(each interface{}) error {
+				values = append(values, each.(*Sample).Value)
+				return nil
+			})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got, want := strings.Join(values, ","), "1,2,3"; got != want {
+			t.Errorf("got %v want %v", got, want)
+		}
+	}
+}
+
+func TestReadJSONArrayStream(t *testing.T) {
+	const size = 10000
+	reader, writer := io.Pipe()
+	firstRead := make(chan struct{})
+	go func() {
+		io.WriteString(writer, `[{"Value":"0"}`)
+		// the rest is only written after the first element was passed to each
+		<-firstRead
+		for i := 1; i < size; i++ {
+			io.WriteString(writer, `,{"Value":"`+strconv.Itoa(i)+`"}`)
+		}
+		io.WriteString(writer, "]")
+		writer.Close()
+	}()
+	httpRequest, _ := http.NewRequest("POST", "/ingest", reader)
+	count := 0
+	err := NewRequest(httpRequest).ReadJSONArrayStream(
+		func() interface{} { return new(Sample) },
+		func(each interface{}) error {
+			if got, want := each.(*Sample).Value, strconv.Itoa(count); got != want {
+				t.Fatalf("got %v want %v", got, want)
+			}
+			if count == 0 {
+				close(firstRead)
+			}
+			count++
+			return nil
+		})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != size {
+		t.Errorf("got %v want %v", count, size)
+	}
+}
+
+func TestReadJSONArrayStreamErrors(t *testing.T) {
+	for body, want := range map[string]string{
+		`{"Value":"1"}`:                "[ServiceError:400] 400: Invalid JSON array, expected [ at offset 1",
+		`[{"Value":"1"},{"Value":}]`:   "malformed JSON at offset 25: invalid character '}' after array element",
+		`[{"Value":"1"},{"Value":"2"}`: "malformed JSON at offset 28: unexpected end of JSON input",
+		``:                             ErrEmptyBody.Error(),
+	} {
+		httpRequest, _ := http.NewRequest("POST", "/ingest", strings.NewReader(body))
+		err := NewRequest(httpRequest).ReadJSONArrayStream(
+			func() interface{} { return new(Sample) },
+			func(each interface{}) error { return nil })
+		if err == nil || err.Error() != want {
+			t.Errorf("[%s] got %v want %v", body, err, want)
+		}
+	}
+}
+
+func TestReadEntitiesStopsOnError(t *testing.T) {
+	httpRequest, _ := http.NewRequest("POST", "/ingest", strings.NewReader("{\"Value\":\"1\"}\n{\"Value\":\"2\"}\n"))
+	count := 0
+	stop := errors.New("stop")
+	err := NewRequest(httpRequest).ReadEntities(
+		func() interface{} { return new(Sample) },
+		func(each interface{}) error {
+			count++
+			return stop
+		})
+	if err != stop || count != 1 {
+		t.Errorf("got %v,%d want %v,1", err, count, stop)
+	}
+}
@@ -5,9 +5,16 @@ package restful
 // that can be found in the LICENSE file.
 
 import (
+	"bufio"
 	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net"
 	"net/http"
+	"reflect"
 	"strings"
+	"time"
 )
 
 // DEPRECATED, use DefaultResponseContentType(mime)
@@ -20,17 +27,30 @@ var PrettyPrintResponses = true
 // It provides several convenience methods to prepare and write response content.
 type Response struct {
 	http.ResponseWriter
-	requestAccept string   // mime-type what the Http Request says it wants to receive
-	routeProduces []string // mime-types what the Route says it can produce
-	statusCode    int      // HTTP status code that has been written explicity (if zero then net/http has written 200)
-	contentLength int      // number of bytes written for the response body
-	prettyPrint   bool     // controls the indentation feature of XML and JSON serialization. It is initialized using var PrettyPrintResponses.
-	err           error    // err property is kept when WriteError is called
+	requestAccept         string   // mime-type what the Http Request says it wants to receive
+	requestAcceptLanguage string   // languages the Http Request says it prefers ; used for localized error messages
+	routeProduces         []string // mime-types what the Route says it can produce
+	statusCode            int      // HTTP status code that has been written explicity (if zero then net/http has written 200)
+	contentLength         int      // number of bytes written for the response body
+	prettyPrint           bool     // controls the indentation feature of XML and JSON serialization. It is initialized using var PrettyPrintResponses.
+	err                   error    // err property is kept when WriteError is called
+
+	errorContentType    string                // if set then ServiceErrors are written using the EntityWriter of this MIME type
+	routeResponseErrors map[int]ResponseError // responses documented by the Route using Returns ; used to complete ServiceErrors
+	serviceAccessors    *entityReaderWriters  // accessors registered for the WebService ; consulted before the global ones
+	routeAccessors      *accessorCache        // negotiated accessors of the selected Route, by Accept header
+	hijacked            bool                  // true if the connection is taken over using Hijack
+	written             bool                  // true if WriteHeader or Write was called
+	defaultContentType  string                // if set then used as Content-Type if none was set before writing content
 }
 
 // Creates a new response based on a http ResponseWriter.
 func NewResponse(httpWriter http.ResponseWriter) *Response {
-	return &Response{httpWriter, "", []string{}, http.StatusOK, 0, PrettyPrintResponses, nil} // empty content-types
+	return &Response{
+		ResponseWriter: httpWriter,
+		routeProduces:  []string{}, // empty content-types
+		statusCode:     http.StatusOK,
+		prettyPrint:    PrettyPrintResponses}
 }
 
 // If Accept header matching fails, fall back to this type.
@@ -68,38 +88,45 @@ func (r *Response) SetRequestAccepts(mime string) {
 // can write according to what the request wants (Accept) and what the Route can produce or what the restful defaults say.
 // If called before WriteEntity and WriteHeader then a false return value can be used to write a 406: Not Acceptable.
 func (r *Response) EntityWriter() (EntityReaderWriter, bool) {
+	if r.routeAccessors != nil {
+		key := accessorCacheKey{accept: r.requestAccept + " " + DefaultResponseMimeType, accessor: r.serviceAccessors}
+		return r.routeAccessors.writerFor(key, r.negotiateEntityWriter)
+	}
+	return r.negotiateEntityWriter()
+}
+
+// negotiateEntityWriter looks up the EntityWriter for the Accept header of the request in the registries.
+func (r *Response) negotiateEntityWriter() (EntityReaderWriter, bool) {
 	for _, qualifiedMime := range strings.Split(r.requestAccept, ",") {
 		mime := strings.Trim(strings.Split(qualifiedMime, ";")[0], " ")
 		if 0 == len(mime) || mime == "*/*" {
+			// missing Accept is the same as */* ; use the first producible type that has a registered writer
 			for _, each := range r.routeProduces {
-				if MIME_JSON == each {
-					return entityAccessRegistry.AccessorAt(MIME_JSON)
-				}
-				if MIME_XML == each {
-					return entityAccessRegistry.AccessorAt(MIME_XML)
+				if writer, ok := accessorAt(r.serviceAccessors, each); ok {
+					return writer, true
 				}
 			}
 		} else { // mime is not blank; see if we have a match in Produces
 			for _, each := range r.routeProduces {
-				if mime == each {
-					if MIME_JSON == each {
-						return entityAccessRegistry.AccessorAt(MIME_JSON)
+				if strings.EqualFold(mime, each) {
+					if strings.EqualFold(MIME_JSON, each) {
+						return accessorAt(r.serviceAccessors, MIME_JSON)
 					}
-					if MIME_XML == each {
-						return entityAccessRegistry.AccessorAt(MIME_XML)
+					if strings.EqualFold(MIME_XML, each) {
+						return accessorAt(r.serviceAccessors, MIME_XML)
 					}
 				}
 			}
 		}
 	}
-	writer, ok := entityAccessRegistry.AccessorAt(r.requestAccept)
+	writer, ok := accessorAt(r.serviceAccessors, r.requestAccept)
 	if !ok {
 		// if not registered then fallback to the defaults (if set)
 		if DefaultResponseMimeType == MIME_JSON {
-			return entityAccessRegistry.AccessorAt(MIME_JSON)
+			return accessorAt(r.serviceAccessors, MIME_JSON)
 		}
 		if DefaultResponseMimeType == MIME_XML {
-			return entityAccessRegistry.AccessorAt(MIME_XML)
+			return accessorAt(r.serviceAccessors, MIME_XML)
 		}
 		if trace {
 			traceLogger.Printf("no registered EntityReaderWriter found for %s", r.requestAccept)
@@ -117,18 +144,101 @@ func (r *Response) WriteEntity(value interface{}) error {
 // If no Accept header is specified (or */*) then respond with the Content-Type as specified by the first in the Route.Produces.
 // If an Accept header is specified then respond with the Content-Type as specified by the first in the Route.Produces that is matched with the Accept header.
 // If the value is nil then no response is send except for the Http status. You may want to call WriteHeader(http.StatusNotFound) instead.
-// If there is no writer available that can represent the value in the requested MIME type then Http Status NotAcceptable is written.
+// If there is no writer available that can represent the value in the requested MIME type then Http Status NotAcceptable is written,
+// with the localized message if one is registered (see RegisterErrorMessages).
 // Current implementation ignores any q-parameters in the Accept Header.
 // Returns an error if the value could not be written on the response.
 func (r *Response) WriteHeaderAndEntity(status int, value interface{}) error {
 	writer, ok := r.EntityWriter()
 	if !ok {
+		if len(r.errorContentType) > 0 {
+			writeServiceError(NewError(http.StatusNotAcceptable, "406: Not Acceptable"), nil, r)
+			return nil
+		}
+		if msg, ok := errorMessageCatalog.MessageFor(http.StatusNotAcceptable, r.requestAcceptLanguage); ok {
+			return r.WriteErrorString(http.StatusNotAcceptable, msg)
+		}
 		r.WriteHeader(http.StatusNotAcceptable)
 		return nil
 	}
 	return writer.Write(r, status, value)
 }
 
+// WriteEntityIfMatch writes the value with the ETag Header set to the etag, if the If-Match precondition
+// of the request is satisfied (see Request.MatchesIfMatch). Otherwise Http Status PreconditionFailed (412) is written.
+func (r *Response) WriteEntityIfMatch(req *Request, etag string, value interface{}) error {
+	if !req.MatchesIfMatch(etag) {
+		return r.WriteErrorString(http.StatusPreconditionFailed, "412: Precondition Failed")
+	}
+	r.Header().Set(HEADER_ETag, etag)
+	return r.WriteEntity(value)
+}
+
+// WriteAsContentType marshals the value using the EntityWriter registered for the given MIME type.
+// It bypasses the content negotiation that uses the Accept Header and the Route.Produces.
+// Returns an error (and writes nothing) if no EntityWriter is registered for the contentType.
+func (r *Response) WriteAsContentType(status int, contentType string, value interface{}) error {
+	writer, ok := accessorAt(r.serviceAccessors, contentType)
+	if !ok {
+		return fmt.Errorf("no registered EntityReaderWriter found for %s", contentType)
+	}
+	return writer.Write(r, status, value)
+}
+
+// WriteReader writes the status and copies the content of the reader to the response, without buffering.
+func (r *Response) WriteReader(status int, contentType string, reader io.Reader) error {
+	r.Header().Set(HEADER_ContentType, contentType)
+	r.WriteHeader(status)
+	_, err := io.Copy(r, reader)
+	return err
+}
+
+// WriteFile is like WriteReader but also sets the Content-Disposition Header such that clients save the content as the filename.
+func (r *Response) WriteFile(status int, contentType, filename string, reader io.Reader) error {
+	r.Header().Set(HEADER_ContentDisposition, mime.FormatMediaType("attachment", map[string]string{"filename": filename}))
+	return r.WriteReader(status, contentType, reader)
+}
+
+// WriteNoContent writes Http Status NoContent (204) without a Content-Type Header and content.
+func (r *Response) WriteNoContent() {
+	r.writeWithoutContent(http.StatusNoContent)
+}
+
+// WriteResetContent writes Http Status ResetContent (205) without a Content-Type Header and content.
+func (r *Response) WriteResetContent() {
+	r.writeWithoutContent(http.StatusResetContent)
+}
+
+// writeWithoutContent removes the Headers that describe content and writes the status.
+func (r *Response) writeWithoutContent(status int) {
+	r.Header().Del(HEADER_ContentType)
+	r.Header().Del("Content-Length")
+	r.defaultContentType = "" // no content follows
+	r.WriteHeader(status)
+}
+
+// WriteRedirect sets the Location Header and writes the redirection status, e.g. http.StatusFound, without content.
+// An error is returned, and nothing is written, if the status is not one of 300, 301, 302, 303, 307 or 308.
+// Note that 304 (Not Modified) is not a redirection and must not have a Location.
+func (r *Response) WriteRedirect(status int, location string) error {
+	if !isRedirectStatus(status) {
+		return fmt.Errorf("status %d is not a redirection (300, 301, 302, 303, 307 or 308)", status)
+	}
+	r.Header().Set(HEADER_Location, location)
+	r.writeWithoutContent(status)
+	return nil
+}
+
+// isRedirectStatus returns whether the status is a redirection that uses the Location Header.
+func isRedirectStatus(status int) bool {
+	switch status {
+	case http.StatusMultipleChoices, http.StatusMovedPermanently, http.StatusFound, http.StatusSeeOther,
+		http.StatusTemporaryRedirect, http.StatusPermanentRedirect:
+		return true
+	}
+	return false
+}
+
 // WriteAsXml is a convenience method for writing a value in xml (requires Xml tags on the value)
 // It uses the standard encoding/xml package for marshalling the valuel ; not using a registered EntityReaderWriter.
 func (r *Response) WriteAsXml(value interface{}) error {
@@ -165,12 +275,87 @@ func (r *Response) WriteError(httpStatus int, err error) error {
 	return r.WriteErrorString(httpStatus, err.Error())
 }
 
-// WriteServiceError is a convenience method for a responding with a status and a ServiceError
+// WriteErrorAuto writes the status and entity that a registered ErrorMapper (see RegisterErrorMapper) returns for the error.
+// If no mapper maps the error then a ServiceError is written with its own code and any other error is written
+// with Http Status InternalServerError (500). A mapped status without an entity is written without content.
+// A wrapped ServiceError (see errors.As) is written with its own code too ; a nil error is written as 500.
+func (r *Response) WriteErrorAuto(err error) error {
+	r.err = err
+	if err == nil {
+		return r.WriteErrorString(http.StatusInternalServerError, "500: Internal Server Error")
+	}
+	if status, entity, ok := errorMapperRegistry.Map(err); ok {
+		if entity == nil {
+			r.WriteHeader(status)
+			return nil
+		}
+		return r.WriteHeaderAndEntity(status, entity)
+	}
+	var serviceErr ServiceError
+	if errors.As(err, &serviceErr) {
+		werr := r.WriteServiceError(serviceErr.Code, serviceErr)
+		r.err = err // keep the wrapping error
+		return werr
+	}
+	return r.WriteError(http.StatusInternalServerError, err)
+}
+
+// WriteServiceError is a convenience method for a responding with a status and a ServiceError.
+// If the Route documents the status using Returns then a missing Code or Message is taken from that declaration.
+// If the declared model is a struct other than ServiceError then a new value of its type is written instead,
+// with its Code, Message and Details fields (if present) set from the ServiceError.
 func (r *Response) WriteServiceError(httpStatus int, err ServiceError) error {
+	declared, ok := r.routeResponseErrors[httpStatus]
+	if ok {
+		if err.Code == 0 {
+			err.Code = httpStatus
+		}
+		if len(err.Message) == 0 {
+			err.Message = declared.Message
+		}
+	}
 	r.err = err
+	if model, ok := declaredErrorModel(declared.Model, err); ok {
+		return r.WriteHeaderAndEntity(httpStatus, model)
+	}
 	return r.WriteHeaderAndEntity(httpStatus, err)
 }
 
+// declaredErrorModel returns a new value of the struct type of the model with the fields of the ServiceError copied.
+// It returns false if the model is not a struct (pointer) or is a ServiceError.
+func declaredErrorModel(model interface{}, err ServiceError) (interface{}, bool) {
+	if model == nil {
+		return nil, false
+	}
+	modelType := reflect.TypeOf(model)
+	if modelType.Kind() == reflect.Ptr {
+		modelType = modelType.Elem()
+	}
+	if modelType.Kind() != reflect.Struct || modelType == reflect.TypeOf(err) {
+		return nil, false
+	}
+	value := reflect.New(modelType).Elem()
+	source := reflect.ValueOf(err)
+	for _, name := range []string{"Code", "Message", "Details"} {
+		field := value.FieldByName(name)
+		if field.IsValid() && field.CanSet() && source.FieldByName(name).Type().AssignableTo(field.Type()) {
+			field.Set(source.FieldByName(name))
+		}
+	}
+	return value.Interface(), true
+}
+
+// WriteReadEntityError responds to an error returned by Request.ReadEntity.
+// A ServiceError is written with its own code. Malformed content (SyntaxError), which includes the offset
+// of the error, an empty body (ErrEmptyBody) and any other error result in Http Status BadRequest (400).
+func (r *Response) WriteReadEntityError(err error) error {
+	r.err = err
+	if serviceErr, ok := err.(ServiceError); ok {
+		return r.WriteErrorString(serviceErr.Code, serviceErr.Message)
+	}
+	return r.WriteErrorString(http.StatusBadRequest, "400: "+err.Error())
+}
+
 // WriteErrorString is a convenience method for an error status with the actual error
 func (r *Response) WriteErrorString(httpStatus int, errorReason string) error {
 	if r.err == nil {
@@ -187,10 +372,25 @@ func (r *Response) WriteErrorString(httpStatus int, errorReason string) error {
 // WriteHeader is overridden to remember the Status Code that has been written.
 // Changes to the Header of the response have no effect after this.
 func (r *Response) WriteHeader(httpStatus int) {
+	if r.hijacked {
+		return
+	}
+	if httpStatus >= http.StatusOK && httpStatus != http.StatusNoContent && httpStatus != http.StatusNotModified {
+		r.applyDefaultContentType()
+	}
 	r.statusCode = httpStatus
+	r.written = true
 	r.ResponseWriter.WriteHeader(httpStatus)
 }
 
+// applyDefaultContentType sets the Content-Type to the default of the WebService, if any, unless it is set already.
+func (r *Response) applyDefaultContentType() {
+	if r.written || len(r.defaultContentType) == 0 || len(r.Header().Get(HEADER_ContentType)) > 0 {
+		return
+	}
+	r.Header().Set(HEADER_ContentType, r.defaultContentType)
+}
+
 // StatusCode returns the code that has been written using WriteHeader.
 func (r Response) StatusCode() int {
 	if 0 == r.statusCode {
@@ -203,11 +403,23 @@ func (r Response) StatusCode() int {
 // Write writes the data to the connection as part of an HTTP reply.
 // Write is part of http.ResponseWriter interface.
 func (r *Response) Write(bytes []byte) (int, error) {
+	if r.hijacked {
+		return 0, http.ErrHijacked
+	}
+	r.applyDefaultContentType()
+	r.written = true
 	written, err := r.ResponseWriter.Write(bytes)
 	r.contentLength += written
 	return written, err
 }
 
+// Written returns whether the status or content of the response has been written (using WriteHeader or Write)
+// or the connection has been hijacked. A filter can use it to decide whether it is still safe to write a status.
+// Data written directly using the underlying http.ResponseWriter is not accounted for.
+func (r Response) Written() bool {
+	return r.written || r.hijacked
+}
+
 // ContentLength returns the number of bytes written for the response content.
 // Note that this value is only correct if all data is written through the Response using its Write* methods.
 // Data written directly using the underlying http.ResponseWriter is not accounted for.
@@ -220,6 +432,47 @@ func (r Response) CloseNotify() <-chan bool {
 	return r.ResponseWriter.(http.CloseNotifier).CloseNotify()
 }
 
+// Hijack is part of the http.Hijacker interface and lets the caller take over the connection, e.g. for WebSockets.
+// Returns an error if the underlying http.ResponseWriter does not support it.
+// After a successful Hijack, writing the status or content using the Response is ignored.
+func (r *Response) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	// a http.ResponseController finds the http.Hijacker below wrappers such as the CompressingResponseWriter
+	conn, buffer, err := http.NewResponseController(r.ResponseWriter).Hijack()
+	if errors.Is(err, http.ErrNotSupported) {
+		return nil, nil, fmt.Errorf("http.ResponseWriter of type %T does not implement http.Hijacker: %w", r.ResponseWriter, err)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	r.hijacked = true
+	// the connection is no longer ours to write compressed content to
+	for writer := r.ResponseWriter; writer != nil; {
+		if compressing, ok := writer.(*CompressingResponseWriter); ok {
+			compressing.skip()
+		}
+		wrapper, ok := writer.(interface{ Unwrap() http.ResponseWriter })
+		if !ok {
+			break
+		}
+		writer = wrapper.Unwrap()
+	}
+	return conn, buffer, nil
+}
+
+// SetWriteDeadline sets the deadline for writing the response, e.g. to limit the time spent on slow clients.
+// A zero value means no deadline. Returns an error that wraps http.ErrNotSupported if the underlying
+// http.ResponseWriter does not support it.
+func (r *Response) SetWriteDeadline(deadline time.Time) error {
+	if r.hijacked {
+		return http.ErrHijacked
+	}
+	err := http.NewResponseController(r.ResponseWriter).SetWriteDeadline(deadline)
+	if errors.Is(err, http.ErrNotSupported) {
+		return fmt.Errorf("http.ResponseWriter of type %T does not support write deadlines: %w", r.ResponseWriter, err)
+	}
+	return err
+}
+
 // Error returns the err created by WriteError
 func (r Response) Error() error {
 	return r.err
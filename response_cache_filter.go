@@ -0,0 +1,162 @@
+package restful
+
+// Copyright 2026 agent. All rights reserved.
+// Use of this source code is governed by a license
+// that can be found in the LICENSE file.
+
+import (
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// CachedResponse is the recorded status, headers and content of a response, to serve until it expires.
+// Vary holds the values of the request headers named by the Vary header of the response.
+type CachedResponse struct {
+	StatusCode int
+	Header     http.Header
+	Body       []byte
+	Expires    time.Time
+	Vary       http.Header
+}
+
+// CacheStore keeps the responses of requests by their cache key.
+// Implementations must be safe for concurrent use ; expired responses are ignored by the filter.
+type CacheStore interface {
+	// Get returns the response that was stored for the key, if any.
+	Get(key string) (*CachedResponse, bool)
+	// Put stores the response for the key.
+	Put(key string, response *CachedResponse)
+}
+
+// maxCachedResponses limits the number of responses kept by the memoryCacheStore.
+const maxCachedResponses = 1024
+
+// memoryCacheStore is a CacheStore that keeps responses in memory until they are expired.
+type memoryCacheStore struct {
+	protection *sync.Mutex
+	responses  map[string]*CachedResponse
+	maxSize    int
+}
+
+// NewMemoryCacheStore returns a CacheStore that keeps at most 1024 responses in memory.
+// Expired responses are removed when requested or when the store is full ;
+// if none has expired then the response that expires first is removed to make room.
+func NewMemoryCacheStore() CacheStore {
+	return memoryCacheStore{protection: new(sync.Mutex), responses: map[string]*CachedResponse{}, maxSize: maxCachedResponses}
+}
+
+// Get is part of CacheStore
+func (m memoryCacheStore) Get(key string) (*CachedResponse, bool) {
+	m.protection.Lock()
+	defer m.protection.Unlock()
+	response, ok := m.responses[key]
+	if ok && !time.Now().Before(response.Expires) {
+		delete(m.responses, key)
+		return nil, false
+	}
+	return response, ok
+}
+
+// Put is part of CacheStore
+func (m memoryCacheStore) Put(key string, response *CachedResponse) {
+	m.protection.Lock()
+	defer m.protection.Unlock()
+	if _, ok := m.responses[key]; !ok && len(m.responses) >= m.maxSize {
+		m.evict()
+	}
+	m.responses[key] = response
+}
+
+// evict removes all expired responses or else the one that expires first. Must be called with the lock held.
+func (m memoryCacheStore) evict() {
+	now := time.Now()
+	first := ""
+	for key, each := range m.responses {
+		if !now.Before(each.Expires) {
+			delete(m.responses, key)
+			continue
+		}
+		if len(first) == 0 || each.Expires.Before(m.responses[first].Expires) {
+			first = key
+		}
+	}
+	if len(m.responses) >= m.maxSize {
+		delete(m.responses, first)
+	}
+}
+
+// NewResponseCacheFilter returns a filter that, for requests with a safe method (e.g. GET), serves the response
+// stored for the same key if it has not expired. Otherwise the request is passed on and its response is stored,
+// for the duration of ttl, if it has status OK (200) and no "Vary: *" header. If keyFunc is nil then the key
+// is composed of the method, the request URI and the Accept header.
+// A stored response is only served to requests that have the same values for the headers named by its Vary header ;
+// a response for other values replaces it.
+func NewResponseCacheFilter(ttl time.Duration, keyFunc func(*Request) string, store CacheStore) FilterFunction {
+	if keyFunc == nil {
+		keyFunc = func(req *Request) string {
+			return req.Request.Method + " " + req.Request.URL.RequestURI() + " " + req.HeaderParameter(HEADER_Accept)
+		}
+	}
+	return func(req *Request, resp *Response, chain *FilterChain) {
+		if !isSafeMethod(req.Request.Method) {
+			chain.ProcessFilter(req, resp)
+			return
+		}
+		key := keyFunc(req)
+		if cached, ok := store.Get(key); ok && time.Now().Before(cached.Expires) && sameVaryValues(cached.Vary, req.Request.Header) {
+			for name, values := range cached.Header {
+				resp.Header()[name] = append([]string{}, values...)
+			}
+			resp.WriteHeader(cached.StatusCode)
+			resp.Write(cached.Body)
+			return
+		}
+		recorder := &recordingResponseWriter{ResponseWriter: resp.ResponseWriter, statusCode: http.StatusOK}
+		resp.ResponseWriter = recorder
+		chain.ProcessFilter(req, resp)
+		resp.ResponseWriter = recorder.ResponseWriter
+		if recorder.statusCode != http.StatusOK {
+			return
+		}
+		vary, ok := varyValues(resp.Header(), req.Request.Header)
+		if !ok {
+			return
+		}
+		store.Put(key, &CachedResponse{
+			StatusCode: recorder.statusCode,
+			Header:     replayableHeader(resp.Header()),
+			Body:       recorder.body.Bytes(),
+			Expires:    time.Now().Add(ttl),
+			Vary:       vary})
+	}
+}
+
+// varyValues returns the values of the request headers named by the Vary header of the response.
+// It returns false if the response varies on anything ("*") and therefore cannot be cached.
+func varyValues(responseHeader, requestHeader http.Header) (http.Header, bool) {
+	vary := http.Header{}
+	for _, each := range responseHeader[HEADER_Vary] {
+		for _, name := range strings.Split(each, ",") {
+			name = strings.TrimSpace(name)
+			if name == "*" {
+				return nil, false
+			}
+			if len(name) > 0 {
+				vary[http.CanonicalHeaderKey(name)] = append([]string{}, requestHeader[http.CanonicalHeaderKey(name)]...)
+			}
+		}
+	}
+	return vary, true
+}
+
+// sameVaryValues returns whether the request has the same values for the headers that were recorded in vary.
+func sameVaryValues(vary, requestHeader http.Header) bool {
+	for name, values := range vary {
+		if strings.Join(values, ",") != strings.Join(requestHeader[name], ",") {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,133 @@
+package restful
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestResponseCacheFilter(t *testing.T) {
+	computed := 0
+	ws := new(WebService).Path("/reports")
+	ws.Filter(NewResponseCacheFilter(50*time.Millisecond, nil, NewMemoryCacheStore()))
+	ws.Route(ws.GET("").To(func(req *Request, resp *Response) {
+		computed++
+		resp.Header().Set("X-Report", fmt.Sprintf("%d", computed))
+		io.WriteString(resp, fmt.Sprintf("report %d", computed))
+	}))
+	ws.Route(ws.POST("").To(func(req *Request, resp *Response) {
+		computed++
+		io.WriteString(resp, fmt.Sprintf("report %d", computed))
+	}))
+	c := NewContainer().Add(ws)
+	get := func(method string) *httptest.ResponseRecorder {
+		httpRequest, _ := http.NewRequest(method, "/reports", nil)
+		httpWriter := httptest.NewRecorder()
+		c.dispatch(httpWriter, httpRequest)
+		return httpWriter
+	}
+	first := get("GET")
+	if got, want := first.Body.String(), "report 1"; got != want {
+		t.Errorf("got %v want %v", got, want)
+	}
+	hit := get("GET")
+	if got, want := hit.Body.String(), "report 1"; got != want {
+		t.Errorf("got %v want %v from cache", got, want)
+	}
+	if got, want := hit.Header().Get("X-Report"), "1"; got != want {
+		t.Errorf("got %v want %v from cache", got, want)
+	}
+	if got, want := get("POST").Body.String(), "report 2"; got != want {
+		t.Errorf("got %v want %v, not cached", got, want)
+	}
+	time.Sleep(60 * time.Millisecond)
+	if got, want := get("GET").Body.String(), "report 3"; got != want {
+		t.Errorf("got %v want %v after expiry", got, want)
+	}
+}
+
+func TestMemoryCacheStoreExpiry(t *testing.T) {
+	store := NewMemoryCacheStore()
+	store.Put("fresh", &CachedResponse{Expires: time.Now().Add(time.Minute)})
+	store.Put("stale", &CachedResponse{Expires: time.Now().Add(-time.Second)})
+	if _, ok := store.Get("fresh"); !ok {
+		t.Error("expected fresh response")
+	}
+	if _, ok := store.Get("stale"); ok {
+		t.Error("expected stale response to be removed")
+	}
+}
+
+func TestResponseCacheFilterVary(t *testing.T) {
+	computed := 0
+	ws := new(WebService).Path("/reports")
+	ws.Filter(NewResponseCacheFilter(time.Minute, nil, NewMemoryCacheStore()))
+	ws.Route(ws.GET("").To(func(req *Request, resp *Response) {
+		computed++
+		resp.Header().Set(HEADER_Vary, "Accept-Language")
+		resp.Header().Set("X-Report", fmt.Sprintf("%d", computed))
+		io.WriteString(resp, req.HeaderParameter(HEADER_AcceptLanguage))
+	}))
+	ws.Route(ws.GET("/live").To(func(req *Request, resp *Response) {
+		computed++
+		resp.Header().Set(HEADER_Vary, "*")
+		io.WriteString(resp, fmt.Sprintf("live %d", computed))
+	}))
+	c := NewContainer().Add(ws)
+	get := func(path, language string) *httptest.ResponseRecorder {
+		httpRequest, _ := http.NewRequest("GET", path, nil)
+		httpRequest.Header.Set(HEADER_AcceptLanguage, language)
+		httpWriter := httptest.NewRecorder()
+		c.dispatch(httpWriter, httpRequest)
+		return httpWriter
+	}
+	first := get("/reports", "nl")
+	// changing the served header must not change the cached response
+	first.Header()["X-Report"][0] = "changed"
+	for _, each := range []struct {
+		language string
+		body     string
+		report   string
+	}{
+		{"nl", "nl", "1"}, // hit
+		{"en", "en", "2"}, // different Accept-Language, replaces
+		{"en", "en", "2"}, // hit
+	} {
+		httpWriter := get("/reports", each.language)
+		if got, want := httpWriter.Body.String(), each.body; got != want {
+			t.Errorf("[%s] got %v want %v", each.language, got, want)
+		}
+		if got, want := httpWriter.Header().Get("X-Report"), each.report; got != want {
+			t.Errorf("[%s] got %v want %v", each.language, got, want)
+		}
+	}
+	get("/reports/live", "nl")
+	if got, want := get("/reports/live", "nl").Body.String(), "live 4"; got != want {
+		t.Errorf("got %v want %v, not cached", got, want)
+	}
+}
+
+func TestMemoryCacheStoreBounded(t *testing.T) {
+	store := memoryCacheStore{protection: new(sync.Mutex), responses: map[string]*CachedResponse{}, maxSize: 3}
+	store.Put("stale", &CachedResponse{Expires: time.Now().Add(-time.Second)})
+	store.Put("later", &CachedResponse{Expires: time.Now().Add(2 * time.Minute)})
+	store.Put("first", &CachedResponse{Expires: time.Now().Add(time.Minute)})
+	store.Put("fresh", &CachedResponse{Expires: time.Now().Add(3 * time.Minute)})
+	if got, want := len(store.responses), 3; got != want {
+		t.Fatalf("got %v want %v", got, want)
+	}
+	if _, ok := store.responses["stale"]; ok {
+		t.Error("expected stale response to be evicted")
+	}
+	store.Put("last", &CachedResponse{Expires: time.Now().Add(4 * time.Minute)})
+	if got, want := len(store.responses), 3; got != want {
+		t.Fatalf("got %v want %v", got, want)
+	}
+	if _, ok := store.responses["first"]; ok {
+		t.Error("expected response that expires first to be evicted")
+	}
+}
@@ -1,16 +1,22 @@
 package restful
 
 import (
+	"encoding/json"
 	"errors"
+	"io"
+	"io/ioutil"
+	"net"
 	"net/http"
 	"net/http/httptest"
+	"reflect"
 	"strings"
 	"testing"
+	"time"
 )
 
 func TestWriteHeader(t *testing.T) {
 	httpWriter := httptest.NewRecorder()
-	resp := Response{httpWriter, "*/*", []string{"*/*"}, 0, 0, true, nil}
+	resp := Response{ResponseWriter: httpWriter, requestAccept: "*/*", routeProduces: []string{"*/*"}, prettyPrint: true}
 	resp.WriteHeader(123)
 	if resp.StatusCode() != 123 {
 		t.Errorf("Unexpected status code:%d", resp.StatusCode())
@@ -19,7 +25,7 @@ func TestWriteHeader(t *testing.T) {
 
 func TestNoWriteHeader(t *testing.T) {
 	httpWriter := httptest.NewRecorder()
-	resp := Response{httpWriter, "*/*", []string{"*/*"}, 0, 0, true, nil}
+	resp := Response{ResponseWriter: httpWriter, requestAccept: "*/*", routeProduces: []string{"*/*"}, prettyPrint: true}
 	if resp.StatusCode() != http.StatusOK {
 		t.Errorf("Unexpected status code:%d", resp.StatusCode())
 	}
@@ -32,7 +38,7 @@ type food struct {
 // go test -v -test.run TestMeasureContentLengthXml ...restful
 func TestMeasureContentLengthXml(t *testing.T) {
 	httpWriter := httptest.NewRecorder()
-	resp := Response{httpWriter, "*/*", []string{"*/*"}, 0, 0, true, nil}
+	resp := Response{ResponseWriter: httpWriter, requestAccept: "*/*", routeProduces: []string{"*/*"}, prettyPrint: true}
 	resp.WriteAsXml(food{"apple"})
 	if resp.ContentLength() != 76 {
 		t.Errorf("Incorrect measured length:%d", resp.ContentLength())
@@ -42,7 +48,7 @@ func TestMeasureContentLengthXml(t *testing.T) {
 // go test -v -test.run TestMeasureContentLengthJson ...restful
 func TestMeasureContentLengthJson(t *testing.T) {
 	httpWriter := httptest.NewRecorder()
-	resp := Response{httpWriter, "*/*", []string{"*/*"}, 0, 0, true, nil}
+	resp := Response{ResponseWriter: httpWriter, requestAccept: "*/*", routeProduces: []string{"*/*"}, prettyPrint: true}
 	resp.WriteAsJson(food{"apple"})
 	if resp.ContentLength() != 22 {
 		t.Errorf("Incorrect measured length:%d", resp.ContentLength())
@@ -52,7 +58,7 @@ func TestMeasureContentLengthJson(t *testing.T) {
 // go test -v -test.run TestMeasureContentLengthJsonNotPretty ...restful
 func TestMeasureContentLengthJsonNotPretty(t *testing.T) {
 	httpWriter := httptest.NewRecorder()
-	resp := Response{httpWriter, "*/*", []string{"*/*"}, 0, 0, false, nil}
+	resp := Response{ResponseWriter: httpWriter, requestAccept: "*/*", routeProduces: []string{"*/*"}, prettyPrint: false}
 	resp.WriteAsJson(food{"apple"})
 	if resp.ContentLength() != 17 { // 16+1 using the Encoder directly yields another /n
 		t.Errorf("Incorrect measured length:%d", resp.ContentLength())
@@ -62,7 +68,7 @@ func TestMeasureContentLengthJsonNotPretty(t *testing.T) {
 // go test -v -test.run TestMeasureContentLengthWriteErrorString ...restful
 func TestMeasureContentLengthWriteErrorString(t *testing.T) {
 	httpWriter := httptest.NewRecorder()
-	resp := Response{httpWriter, "*/*", []string{"*/*"}, 0, 0, true, nil}
+	resp := Response{ResponseWriter: httpWriter, requestAccept: "*/*", routeProduces: []string{"*/*"}, prettyPrint: true}
 	resp.WriteErrorString(404, "Invalid")
 	if resp.ContentLength() != len("Invalid") {
 		t.Errorf("Incorrect measured length:%d", resp.ContentLength())
@@ -80,7 +86,7 @@ func TestStatusIsPassedToResponse(t *testing.T) {
 		{write: 400, read: 400},
 	} {
 		httpWriter := httptest.NewRecorder()
-		resp := Response{httpWriter, "*/*", []string{"*/*"}, 0, 0, true, nil}
+		resp := Response{ResponseWriter: httpWriter, requestAccept: "*/*", routeProduces: []string{"*/*"}, prettyPrint: true}
 		resp.WriteHeader(each.write)
 		if got, want := httpWriter.Code, each.read; got != want {
 			t.Errorf("got %v want %v", got, want)
@@ -91,7 +97,7 @@ func TestStatusIsPassedToResponse(t *testing.T) {
 // go test -v -test.run TestStatusCreatedAndContentTypeJson_Issue54 ...restful
 func TestStatusCreatedAndContentTypeJson_Issue54(t *testing.T) {
 	httpWriter := httptest.NewRecorder()
-	resp := Response{httpWriter, "application/json", []string{"application/json"}, 0, 0, true, nil}
+	resp := Response{ResponseWriter: httpWriter, requestAccept: "application/json", routeProduces: []string{"application/json"}, prettyPrint: true}
 	resp.WriteHeader(201)
 	resp.WriteAsJson(food{"Juicy"})
 	if httpWriter.HeaderMap.Get("Content-Type") != "application/json" {
@@ -113,7 +119,7 @@ func (e errorOnWriteRecorder) Write(bytes []byte) (int, error) {
 // go test -v -test.run TestLastWriteErrorCaught ...restful
 func TestLastWriteErrorCaught(t *testing.T) {
 	httpWriter := errorOnWriteRecorder{httptest.NewRecorder()}
-	resp := Response{httpWriter, "application/json", []string{"application/json"}, 0, 0, true, nil}
+	resp := Response{ResponseWriter: httpWriter, requestAccept: "application/json", routeProduces: []string{"application/json"}, prettyPrint: true}
 	err := resp.WriteAsJson(food{"Juicy"})
 	if err.Error() != "fail" {
 		t.Errorf("Unexpected error message:%v", err)
@@ -124,7 +130,7 @@ func TestLastWriteErrorCaught(t *testing.T) {
 func TestAcceptStarStar_Issue83(t *testing.T) {
 	httpWriter := httptest.NewRecorder()
 	//								Accept									Produces
-	resp := Response{httpWriter, "application/bogus,*/*;q=0.8", []string{"application/json"}, 0, 0, true, nil}
+	resp := Response{ResponseWriter: httpWriter, requestAccept: "application/bogus,*/*;q=0.8", routeProduces: []string{"application/json"}, prettyPrint: true}
 	resp.WriteEntity(food{"Juicy"})
 	ct := httpWriter.Header().Get("Content-Type")
 	if "application/json" != ct {
@@ -136,7 +142,7 @@ func TestAcceptStarStar_Issue83(t *testing.T) {
 func TestAcceptSkipStarStar_Issue83(t *testing.T) {
 	httpWriter := httptest.NewRecorder()
 	//								Accept									Produces
-	resp := Response{httpWriter, " application/xml ,*/* ; q=0.8", []string{"application/json", "application/xml"}, 0, 0, true, nil}
+	resp := Response{ResponseWriter: httpWriter, requestAccept: " application/xml ,*/* ; q=0.8", routeProduces: []string{"application/json", "application/xml"}, prettyPrint: true}
 	resp.WriteEntity(food{"Juicy"})
 	ct := httpWriter.Header().Get("Content-Type")
 	if "application/xml" != ct {
@@ -148,7 +154,7 @@ func TestAcceptSkipStarStar_Issue83(t *testing.T) {
 func TestAcceptXmlBeforeStarStar_Issue83(t *testing.T) {
 	httpWriter := httptest.NewRecorder()
 	//								Accept									Produces
-	resp := Response{httpWriter, "text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8", []string{"application/json"}, 0, 0, true, nil}
+	resp := Response{ResponseWriter: httpWriter, requestAccept: "text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8", routeProduces: []string{"application/json"}, prettyPrint: true}
 	resp.WriteEntity(food{"Juicy"})
 	ct := httpWriter.Header().Get("Content-Type")
 	if "application/json" != ct {
@@ -159,7 +165,7 @@ func TestAcceptXmlBeforeStarStar_Issue83(t *testing.T) {
 // go test -v -test.run TestWriteHeaderNoContent_Issue124 ...restful
 func TestWriteHeaderNoContent_Issue124(t *testing.T) {
 	httpWriter := httptest.NewRecorder()
-	resp := Response{httpWriter, "text/plain", []string{"text/plain"}, 0, 0, true, nil}
+	resp := Response{ResponseWriter: httpWriter, requestAccept: "text/plain", routeProduces: []string{"text/plain"}, prettyPrint: true}
 	resp.WriteHeader(http.StatusNoContent)
 	if httpWriter.Code != http.StatusNoContent {
 		t.Errorf("got %d want %d", httpWriter.Code, http.StatusNoContent)
@@ -169,7 +175,7 @@ func TestWriteHeaderNoContent_Issue124(t *testing.T) {
 // go test -v -test.run TestStatusCreatedAndContentTypeJson_Issue163 ...restful
 func TestStatusCreatedAndContentTypeJson_Issue163(t *testing.T) {
 	httpWriter := httptest.NewRecorder()
-	resp := Response{httpWriter, "application/json", []string{"application/json"}, 0, 0, true, nil}
+	resp := Response{ResponseWriter: httpWriter, requestAccept: "application/json", routeProduces: []string{"application/json"}, prettyPrint: true}
 	resp.WriteHeader(http.StatusNotModified)
 	if httpWriter.Code != http.StatusNotModified {
 		t.Errorf("Got %d want %d", httpWriter.Code, http.StatusNotModified)
@@ -178,7 +184,7 @@ func TestStatusCreatedAndContentTypeJson_Issue163(t *testing.T) {
 
 func TestWriteHeaderAndEntity_Issue235(t *testing.T) {
 	httpWriter := httptest.NewRecorder()
-	resp := Response{httpWriter, "application/json", []string{"application/json"}, 0, 0, true, nil}
+	resp := Response{ResponseWriter: httpWriter, requestAccept: "application/json", routeProduces: []string{"application/json"}, prettyPrint: true}
 	var pong = struct {
 		Foo string `json:"foo"`
 	}{Foo: "123"}
@@ -196,9 +202,375 @@ func TestWriteHeaderAndEntity_Issue235(t *testing.T) {
 
 func TestWriteEntityNotAcceptable(t *testing.T) {
 	httpWriter := httptest.NewRecorder()
-	resp := Response{httpWriter, "application/bogus", []string{"application/json"}, 0, 0, true, nil}
+	resp := Response{ResponseWriter: httpWriter, requestAccept: "application/bogus", routeProduces: []string{"application/json"}, prettyPrint: true}
 	resp.WriteEntity("done")
 	if httpWriter.Code != http.StatusNotAcceptable {
 		t.Errorf("got %d want %d", httpWriter.Code, http.StatusNotAcceptable)
 	}
 }
+
+func TestWriteAsContentTypeIgnoresAccept(t *testing.T) {
+	httpWriter := httptest.NewRecorder()
+	resp := Response{ResponseWriter: httpWriter, requestAccept: "application/json", routeProduces: []string{"application/json", "application/xml"}, prettyPrint: true}
+	if err := resp.WriteAsContentType(http.StatusOK, MIME_XML, food{"Juicy"}); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := httpWriter.Header().Get("Content-Type"), MIME_XML; got != want {
+		t.Errorf("got %v want %v", got, want)
+	}
+	if !strings.Contains(httpWriter.Body.String(), "<food>") {
+		t.Errorf("expected food in xml:%s", httpWriter.Body.String())
+	}
+}
+
+func TestWriteAsContentTypeUnregistered(t *testing.T) {
+	httpWriter := httptest.NewRecorder()
+	resp := Response{ResponseWriter: httpWriter, requestAccept: "application/json", routeProduces: []string{"application/json"}, prettyPrint: true}
+	if err := resp.WriteAsContentType(http.StatusOK, "text/csv", food{"Juicy"}); err == nil {
+		t.Error("error expected")
+	}
+	if httpWriter.Body.Len() != 0 {
+		t.Errorf("unexpected body:%s", httpWriter.Body.String())
+	}
+}
+
+func TestHijackNotSupported(t *testing.T) {
+	httpWriter := httptest.NewRecorder()
+	resp := NewResponse(httpWriter)
+	conn, rw, err := resp.Hijack()
+	if err == nil {
+		t.Fatal("error expected")
+	}
+	if conn != nil || rw != nil {
+		t.Error("no connection expected")
+	}
+	if _, err := resp.Write([]byte("still writable")); err != nil {
+		t.Errorf("unexpected error %v", err)
+	}
+}
+
+func TestHijackedResponseIgnoresWrites(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		resp := NewResponse(w)
+		conn, rw, err := resp.Hijack()
+		if err != nil {
+			t.Errorf("unexpected error %v", err)
+			return
+		}
+		defer conn.Close()
+		resp.WriteHeader(http.StatusTeapot)
+		if _, err := resp.Write([]byte("ignored")); err != http.ErrHijacked {
+			t.Errorf("got %v want %v", err, http.ErrHijacked)
+		}
+		rw.WriteString("HTTP/1.1 200 OK\r\nContent-Length: 8\r\nConnection: close\r\n\r\nhijacked")
+		rw.Flush()
+	}))
+	defer server.Close()
+	httpResponse, err := http.Get(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer httpResponse.Body.Close()
+	body, _ := ioutil.ReadAll(httpResponse.Body)
+	if got, want := string(body), "hijacked"; got != want {
+		t.Errorf("got %q want %q", got, want)
+	}
+}
+
+func TestHijackThroughCompressingContainer(t *testing.T) {
+	ws := new(WebService).Path("/ws")
+	ws.Filter(NewServerTimingFilter("total"))
+	ws.Route(ws.GET("").To(func(req *Request, resp *Response) {
+		conn, rw, err := resp.Hijack()
+		if err != nil {
+			t.Errorf("unexpected error %v", err)
+			return
+		}
+		defer conn.Close()
+		rw.WriteString("HTTP/1.1 101 Switching Protocols\r\nUpgrade: websocket\r\nConnection: Upgrade\r\n\r\nhijacked")
+		rw.Flush()
+	}))
+	c := NewContainer().Add(ws)
+	c.EnableContentEncoding(true)
+	server := httptest.NewServer(c)
+	defer server.Close()
+
+	conn, err := net.Dial("tcp", server.Listener.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	io.WriteString(conn, "GET /ws HTTP/1.1\r\nHost: here\r\nAccept-Encoding: gzip\r\nConnection: Upgrade\r\nUpgrade: websocket\r\n\r\n")
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	received, _ := ioutil.ReadAll(conn)
+	if got, want := string(received), "HTTP/1.1 101 Switching Protocols\r\nUpgrade: websocket\r\nConnection: Upgrade\r\n\r\nhijacked"; got != want {
+		t.Errorf("got %q want %q", got, want)
+	}
+}
+
+func TestWriteHeaderAndJsonOrXmlIgnoresAccept(t *testing.T) {
+	for _, pretty := range []bool{true, false} {
+		httpWriter := httptest.NewRecorder()
+		resp := Response{ResponseWriter: httpWriter, requestAccept: "application/xml", routeProduces: []string{"application/xml"}, prettyPrint: pretty}
+		resp.WriteHeaderAndJson(http.StatusCreated, food{"Juicy"}, MIME_JSON)
+		if got, want := httpWriter.Header().Get("Content-Type"), MIME_JSON; got != want {
+			t.Errorf("[pretty:%v] got %v want %v", pretty, got, want)
+		}
+		if got, want := httpWriter.Code, http.StatusCreated; got != want {
+			t.Errorf("[pretty:%v] got %v want %v", pretty, got, want)
+		}
+		if got, want := strings.Contains(httpWriter.Body.String(), "\n "), pretty; got != want {
+			t.Errorf("[pretty:%v] unexpected json:%q", pretty, httpWriter.Body.String())
+		}
+
+		httpWriter = httptest.NewRecorder()
+		resp = Response{ResponseWriter: httpWriter, requestAccept: "application/json", routeProduces: []string{"application/json"}, prettyPrint: pretty}
+		resp.WriteHeaderAndXml(http.StatusAccepted, food{"Juicy"})
+		if got, want := httpWriter.Header().Get("Content-Type"), MIME_XML; got != want {
+			t.Errorf("[pretty:%v] got %v want %v", pretty, got, want)
+		}
+		if got, want := httpWriter.Code, http.StatusAccepted; got != want {
+			t.Errorf("[pretty:%v] got %v want %v", pretty, got, want)
+		}
+		if !strings.Contains(httpWriter.Body.String(), "<Kind>Juicy</Kind>") {
+			t.Errorf("[pretty:%v] unexpected xml:%q", pretty, httpWriter.Body.String())
+		}
+	}
+}
+
+func TestWriteEntityIfMatch(t *testing.T) {
+	for ifMatch, want := range map[string]int{`"v2"`: http.StatusOK, `"v1"`: http.StatusPreconditionFailed} {
+		httpRequest, _ := http.NewRequest("PUT", "/test", nil)
+		httpRequest.Header.Set("If-Match", ifMatch)
+		httpWriter := httptest.NewRecorder()
+		resp := Response{ResponseWriter: httpWriter, requestAccept: "application/json", routeProduces: []string{"application/json"}}
+		resp.WriteEntityIfMatch(NewRequest(httpRequest), `"v2"`, food{"Juicy"})
+		if got := httpWriter.Code; got != want {
+			t.Errorf("[%s] got %v want %v", ifMatch, got, want)
+		}
+		if want == http.StatusOK && httpWriter.Header().Get("ETag") != `"v2"` {
+			t.Errorf("[%s] missing ETag", ifMatch)
+		}
+	}
+}
+
+// go test -v -test.run TestWriteEntityWithoutAccept ...restful
+func TestWriteEntityWithoutAccept(t *testing.T) {
+	kv := new(keyvalue)
+	RegisterEntityAccessor("application/kv", kv)
+	for _, each := range []struct {
+		produces    []string
+		contentType string
+	}{
+		{[]string{MIME_XML, MIME_JSON}, MIME_XML},
+		{[]string{"text/csv", MIME_JSON}, MIME_JSON},
+		{[]string{"application/kv", MIME_JSON}, ""}, // keyvalue does not set it
+	} {
+		httpWriter := httptest.NewRecorder()
+		resp := Response{ResponseWriter: httpWriter, requestAccept: "", routeProduces: each.produces}
+		resp.WriteEntity(food{"Juicy"})
+		if got, want := httpWriter.Code, http.StatusOK; got != want {
+			t.Errorf("%v got %v want %v", each.produces, got, want)
+		}
+		if got, want := httpWriter.Header().Get("Content-Type"), each.contentType; len(want) > 0 && got != want {
+			t.Errorf("%v got %v want %v", each.produces, got, want)
+		}
+	}
+	if !kv.writeCalled {
+		t.Error("Write never called")
+	}
+}
+
+// go test -v -test.run TestWriteDeclaredServiceError ...restful
+func TestWriteDeclaredServiceError(t *testing.T) {
+	c := NewContainer()
+	ws := new(WebService).Path("/books").Produces(MIME_JSON)
+	ws.Route(ws.GET("/{isbn}").Returns(http.StatusNotFound, "Book not found", ServiceError{}).To(func(req *Request, resp *Response) {
+		resp.WriteServiceError(http.StatusNotFound, ServiceError{})
+	}))
+	c.Add(ws)
+	httpRequest, _ := http.NewRequest("GET", "/books/42", nil)
+	httpWriter := httptest.NewRecorder()
+	c.dispatch(httpWriter, httpRequest)
+	if got, want := httpWriter.Code, http.StatusNotFound; got != want {
+		t.Errorf("got %v want %v", got, want)
+	}
+	var written ServiceError
+	if err := json.Unmarshal(httpWriter.Body.Bytes(), &written); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := written, NewError(http.StatusNotFound, "Book not found"); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v want %v", got, want)
+	}
+}
+
+func TestWriteDeclaredServiceErrorModel(t *testing.T) {
+	type problem struct {
+		Code    int    `json:"status"`
+		Message string `json:"title"`
+		Type    string `json:"type"`
+	}
+	c := NewContainer()
+	ws := new(WebService).Path("/books").Produces(MIME_JSON)
+	ws.Route(ws.GET("/{isbn}").Returns(http.StatusNotFound, "Book not found", &problem{}).To(func(req *Request, resp *Response) {
+		resp.WriteServiceError(http.StatusNotFound, ServiceError{})
+	}))
+	c.Add(ws)
+	httpRequest, _ := http.NewRequest("GET", "/books/42", nil)
+	httpWriter := httptest.NewRecorder()
+	c.dispatch(httpWriter, httpRequest)
+	if got, want := httpWriter.Code, http.StatusNotFound; got != want {
+		t.Errorf("got %v want %v", got, want)
+	}
+	var written problem
+	if err := json.Unmarshal(httpWriter.Body.Bytes(), &written); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := written, (problem{Code: http.StatusNotFound, Message: "Book not found"}); got != want {
+		t.Errorf("got %v want %v", got, want)
+	}
+}
+
+// go test -v -test.run TestPrettyPrintToggle ...restful
+func TestPrettyPrintToggle(t *testing.T) {
+	for _, mime := range []string{MIME_JSON, MIME_XML} {
+		for pretty, want := range map[bool]bool{true: true, false: false} {
+			httpWriter := httptest.NewRecorder()
+			resp := NewResponse(httpWriter)
+			resp.SetRequestAccepts(mime)
+			resp.routeProduces = []string{mime}
+			resp.PrettyPrint(pretty)
+			resp.WriteEntity(food{"Juicy"})
+			if got := strings.Contains(strings.TrimSpace(httpWriter.Body.String()), "\n"); got != want {
+				t.Errorf("[%s,%v] got %q", mime, pretty, httpWriter.Body.String())
+			}
+		}
+	}
+}
+
+func TestWriteEntityNDJSON(t *testing.T) {
+	httpWriter := httptest.NewRecorder()
+	resp := Response{ResponseWriter: httpWriter, requestAccept: MIME_NDJSON, routeProduces: []string{MIME_NDJSON}}
+	resp.WriteEntity([]food{{"apple"}, {"pear"}})
+	if got, want := httpWriter.Body.String(), "{\"Kind\":\"apple\"}\n{\"Kind\":\"pear\"}\n"; got != want {
+		t.Errorf("got %q want %q", got, want)
+	}
+	if got, want := httpWriter.Header().Get("Content-Type"), MIME_NDJSON; got != want {
+		t.Errorf("got %v want %v", got, want)
+	}
+}
+
+func TestWriteEntityDefaultsToOK(t *testing.T) {
+	httpWriter := httptest.NewRecorder()
+	resp := Response{ResponseWriter: httpWriter, requestAccept: "application/xml", routeProduces: []string{MIME_JSON, MIME_XML}}
+	resp.WriteEntity(food{"Juicy"})
+	if got, want := httpWriter.Code, http.StatusOK; got != want {
+		t.Errorf("got %v want %v", got, want)
+	}
+	if got, want := resp.StatusCode(), http.StatusOK; got != want {
+		t.Errorf("got %v want %v", got, want)
+	}
+	if got, want := httpWriter.Header().Get("Content-Type"), MIME_XML; got != want {
+		t.Errorf("got %v want %v", got, want)
+	}
+}
+
+func TestSetWriteDeadline(t *testing.T) {
+	resp := NewResponse(httptest.NewRecorder())
+	if err := resp.SetWriteDeadline(time.Now().Add(time.Second)); !errors.Is(err, http.ErrNotSupported) {
+		t.Errorf("got %v want %v", err, http.ErrNotSupported)
+	}
+	var deadlineErr error
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		deadlineErr = NewResponse(w).SetWriteDeadline(time.Now().Add(time.Second))
+	}))
+	defer server.Close()
+	if _, err := http.Get(server.URL); err != nil {
+		t.Fatal(err)
+	}
+	if deadlineErr != nil {
+		t.Errorf("unexpected error %v", deadlineErr)
+	}
+}
+
+func TestWriteFile(t *testing.T) {
+	httpWriter := httptest.NewRecorder()
+	resp := NewResponse(httpWriter)
+	if err := resp.WriteFile(http.StatusOK, "text/csv", "report 2026.csv", strings.NewReader("a,b\n1,2\n")); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := httpWriter.Body.String(), "a,b\n1,2\n"; got != want {
+		t.Errorf("got %q want %q", got, want)
+	}
+	if got, want := httpWriter.Header().Get("Content-Type"), "text/csv"; got != want {
+		t.Errorf("got %v want %v", got, want)
+	}
+	if got, want := httpWriter.Header().Get("Content-Disposition"), `attachment; filename="report 2026.csv"`; got != want {
+		t.Errorf("got %v want %v", got, want)
+	}
+	if got, want := resp.ContentLength(), 8; got != want {
+		t.Errorf("got %v want %v", got, want)
+	}
+}
+
+func TestWriteNoContentAndResetContent(t *testing.T) {
+	for _, each := range []struct {
+		write func(*Response)
+		want  int
+	}{
+		{(*Response).WriteNoContent, http.StatusNoContent},
+		{(*Response).WriteResetContent, http.StatusResetContent},
+	} {
+		httpWriter := httptest.NewRecorder()
+		resp := NewResponse(httpWriter)
+		resp.defaultContentType = MIME_JSON
+		resp.Header().Set(HEADER_ContentType, MIME_JSON)
+		each.write(resp)
+		if got := httpWriter.Code; got != each.want {
+			t.Errorf("got %v want %v", got, each.want)
+		}
+		if got := httpWriter.Body.Len(); got != 0 {
+			t.Errorf("got %v want empty body", got)
+		}
+		if got := httpWriter.Header().Get(HEADER_ContentType); got != "" {
+			t.Errorf("got %v want no Content-Type", got)
+		}
+		if got := resp.StatusCode(); got != each.want {
+			t.Errorf("got %v want %v", got, each.want)
+		}
+	}
+}
+
+func TestWriteRedirect(t *testing.T) {
+	for _, status := range []int{http.StatusMultipleChoices, http.StatusMovedPermanently, http.StatusFound, http.StatusSeeOther,
+		http.StatusTemporaryRedirect, http.StatusPermanentRedirect} {
+		httpWriter := httptest.NewRecorder()
+		resp := NewResponse(httpWriter)
+		resp.defaultContentType = MIME_JSON
+		if err := resp.WriteRedirect(status, "/v2/orders"); err != nil {
+			t.Fatal(err)
+		}
+		if got, want := httpWriter.Code, status; got != want {
+			t.Errorf("got %v want %v", got, want)
+		}
+		if got, want := httpWriter.Header().Get(HEADER_Location), "/v2/orders"; got != want {
+			t.Errorf("[%d] got %v want %v", status, got, want)
+		}
+		if got := httpWriter.Header().Get(HEADER_ContentType); got != "" {
+			t.Errorf("[%d] got %v want no Content-Type", status, got)
+		}
+		if got := httpWriter.Body.Len(); got != 0 {
+			t.Errorf("[%d] got %v want empty body", status, got)
+		}
+	}
+	for _, status := range []int{http.StatusOK, http.StatusNotModified, http.StatusUseProxy, 306} {
+		httpWriter := httptest.NewRecorder()
+		resp := NewResponse(httpWriter)
+		if err := resp.WriteRedirect(status, "/elsewhere"); err == nil {
+			t.Errorf("expected error for status %d", status)
+		}
+		if resp.Written() || len(httpWriter.Header().Get(HEADER_Location)) > 0 {
+			t.Errorf("[%d] expected nothing written", status)
+		}
+	}
+}
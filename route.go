@@ -6,13 +6,25 @@ package restful
 
 import (
 	"bytes"
+	"fmt"
 	"net/http"
+	"net/url"
 	"strings"
+	"sync"
 )
 
 // RouteFunction declares the signature of a function that can be bound to a Route.
 type RouteFunction func(*Request, *Response)
 
+var doPreserveEscapedWildcardPath = false
+
+// SetPreserveEscapedWildcardPath controls whether the value of a wildcard path parameter, e.g. {rest:*},
+// is taken from the escaped URL path such that encoded slashes (%2F) are preserved.
+// Default is false ; the value is joined from the decoded path tokens.
+func SetPreserveEscapedWildcardPath(preserve bool) {
+	doPreserveEscapedWildcardPath = preserve
+}
+
 // Route binds a HTTP Method,Path,Consumes combination to a RouteFunction.
 type Route struct {
 	Method   string
@@ -22,15 +34,26 @@ type Route struct {
 	Function RouteFunction
 	Filters  []FilterFunction
 
+	// if true then the response is never compressed, even if content encoding is enabled for the Container
+	DisableCompression bool
+
 	// cached values for dispatching
 	relativePath string
 	pathParts    []string
 	pathExpr     *pathExpression // cached compilation of relativePath as RegExp
+	accessors    *accessorCache  // negotiated EntityWriters by Accept header
+
+	// true for a HEAD route that produces the same as the GET route with the same path
+	inheritsProduces bool
+
+	// MIME type assumed if the request has no Content-Type header ; see WebService.SetConsumesDefault
+	consumesDefault string
 
 	// documentation
 	Doc                     string
 	Notes                   string
 	Operation               string
+	Tags                    []string // for grouping routes in generated documentation
 	ParameterDocs           []*Parameter
 	ResponseErrors          map[int]ResponseError
 	ReadSample, WriteSample interface{} // structs that model an example request or response payload
@@ -39,17 +62,40 @@ type Route struct {
 // Initialize for Route
 func (r *Route) postBuild() {
 	r.pathParts = tokenizePath(r.Path)
+	r.accessors = newAccessorCache()
 }
 
 // Create Request and Response from their http versions
 func (r *Route) wrapRequestResponse(httpWriter http.ResponseWriter, httpRequest *http.Request) (*Request, *Response) {
 	params := r.extractParameters(httpRequest.URL.Path)
+	if doPreserveEscapedWildcardPath {
+		r.extractEscapedWildcardParameter(httpRequest.URL.EscapedPath(), params)
+	}
 	wrappedRequest := NewRequest(httpRequest)
+	// http.NoBody is kept such that it can still be detected
+	if httpRequest.Body != nil && httpRequest.Body != http.NoBody {
+		wrappedRequest.bodyCounter = &countingReadCloser{ReadCloser: httpRequest.Body}
+		httpRequest.Body = wrappedRequest.bodyCounter
+	}
+	if codings := requestContentCodings(httpRequest.Header); len(codings) > 0 && httpRequest.Body != nil && !hasNoBody(httpRequest) &&
+		isSupportedContentEncoding(codings) {
+		// such that each reader of the body gets the decompressed content ; the last applied coding is undone first
+		for ix := len(codings) - 1; ix >= 0; ix-- {
+			httpRequest.Body = newDecompressingReadCloser(httpRequest.Body, codings[ix])
+		}
+		wrappedRequest.bodyDecompressed = true
+	}
 	wrappedRequest.pathParameters = params
 	wrappedRequest.selectedRoutePath = r.Path
+	wrappedRequest.selectedRoute = r
+	wrappedRequest.routeConsumes = r.Consumes
+	wrappedRequest.consumesDefault = r.consumesDefault
 	wrappedResponse := NewResponse(httpWriter)
 	wrappedResponse.requestAccept = httpRequest.Header.Get(HEADER_Accept)
+	wrappedResponse.requestAcceptLanguage = httpRequest.Header.Get(HEADER_AcceptLanguage)
 	wrappedResponse.routeProduces = r.Produces
+	wrappedResponse.routeResponseErrors = r.ResponseErrors
+	wrappedResponse.routeAccessors = r.accessors
 	return wrappedRequest, wrappedResponse
 }
 
@@ -64,8 +110,27 @@ func (r *Route) dispatchWithFilters(wrappedRequest *Request, wrappedResponse *Re
 	}
 }
 
-// Return whether the mimeType matches to what this Route can produce.
+// EffectiveProduces returns the MIME types this Route produces: the ones set for the Route using RouteBuilder.Produces,
+// or else the ones of its WebService. A HEAD Route without them produces the same as the GET Route with the same path.
+// If empty then the Route only matches requests without a specific Accept (*/*).
+func (r Route) EffectiveProduces() []string {
+	return append([]string{}, r.Produces...)
+}
+
+// EffectiveConsumes returns the MIME types this Route consumes: the ones set for the Route using RouteBuilder.Consumes,
+// or else the ones of its WebService, or else */* (any).
+func (r Route) EffectiveConsumes() []string {
+	if len(r.Consumes) == 0 {
+		return []string{"*/*"}
+	}
+	return append([]string{}, r.Consumes...)
+}
+
+// Return whether the mimeType matches to what this Route can produce. A missing Accept is the same as */*.
 func (r Route) matchesAccept(mimeTypesWithQuality string) bool {
+	if len(mimeTypesWithQuality) == 0 {
+		return true
+	}
 	parts := strings.Split(mimeTypesWithQuality, ",")
 	for _, each := range parts {
 		var withoutQuality string
@@ -74,13 +139,13 @@ func (r Route) matchesAccept(mimeTypesWithQuality string) bool {
 		} else {
 			withoutQuality = each
 		}
-		// trim before compare
+		// trim before compare ; media types are compared case-insensitive (RFC 7231, 3.1.1.1)
 		withoutQuality = strings.Trim(withoutQuality, " ")
 		if withoutQuality == "*/*" {
 			return true
 		}
 		for _, producibleType := range r.Produces {
-			if producibleType == "*/*" || producibleType == withoutQuality {
+			if producibleType == "*/*" || strings.EqualFold(producibleType, withoutQuality) {
 				return true
 			}
 		}
@@ -104,6 +169,9 @@ func (r Route) matchesContentType(mimeTypes string) bool {
 		}
 		// proceed with default
 		mimeTypes = MIME_OCTET
+		if len(r.consumesDefault) > 0 {
+			mimeTypes = r.consumesDefault
+		}
 	}
 
 	parts := strings.Split(mimeTypes, ",")
@@ -114,10 +182,10 @@ func (r Route) matchesContentType(mimeTypes string) bool {
 		} else {
 			contentType = each
 		}
-		// trim before compare
+		// trim before compare ; media types are compared case-insensitive (RFC 7231, 3.1.1.1)
 		contentType = strings.Trim(contentType, " ")
 		for _, consumeableType := range r.Consumes {
-			if consumeableType == "*/*" || consumeableType == contentType {
+			if consumeableType == "*/*" || strings.EqualFold(consumeableType, contentType) {
 				return true
 			}
 		}
@@ -125,13 +193,72 @@ func (r Route) matchesContentType(mimeTypes string) bool {
 	return false
 }
 
+// Matches returns whether this Route is selected by the Http method and the (full) URL path,
+// ignoring the Content-Type and Accept negotiation. Path parameters with regular expressions are matched too.
+func (r Route) Matches(method, path string) bool {
+	if r.Method != method {
+		return false
+	}
+	borrowed := borrowPathTokens(path)
+	defer releasePathTokens(borrowed)
+	matches, _, _ := CurlyRouter{}.matchesRouteByPathTokens(r.pathParts, *borrowed)
+	return matches
+}
+
+// BuildPath returns the path of the Route with each path parameter substituted by its (escaped) value in params.
+// The value of a wildcard parameter, e.g. {rest:*}, may contain slashes. An optional last parameter, e.g. {page?}, may be absent.
+// Returns an error if a value of another parameter is missing.
+func BuildPath(route Route, params map[string]string) (string, error) {
+	var buffer bytes.Buffer
+	tokens := tokenizePath(route.Path)
+	for i, each := range tokens {
+		if !strings.HasPrefix(each, "{") {
+			buffer.WriteString("/")
+			buffer.WriteString(each)
+			continue
+		}
+		name := strings.TrimSuffix(each[1:len(each)-1], "?")
+		wildcard := false
+		if colon := strings.Index(name, ":"); colon != -1 {
+			wildcard = name[colon+1:] == "*"
+			name = name[:colon]
+		}
+		value, ok := params[name]
+		if !ok || len(value) == 0 {
+			if i == len(tokens)-1 && isOptionalParameterToken(each) {
+				break
+			}
+			return "", fmt.Errorf("missing value for path parameter: %s", name)
+		}
+		if !wildcard {
+			buffer.WriteString("/")
+			buffer.WriteString(url.PathEscape(value))
+			continue
+		}
+		for _, segment := range strings.Split(strings.Trim(value, "/"), "/") {
+			buffer.WriteString("/")
+			buffer.WriteString(url.PathEscape(segment))
+		}
+	}
+	if buffer.Len() == 0 {
+		return "/", nil
+	}
+	return buffer.String(), nil
+}
+
 // Extract the parameters from the request url path
 func (r Route) extractParameters(urlPath string) map[string]string {
-	urlParts := tokenizePath(urlPath)
+	borrowed := borrowPathTokens(urlPath)
+	defer releasePathTokens(borrowed)
+	urlParts := *borrowed
 	pathParameters := map[string]string{}
 	for i, key := range r.pathParts {
 		var value string
 		if i >= len(urlParts) {
+			if !isOptionalParameterToken(key) && !strings.HasSuffix(key, ":*}") {
+				// fewer segments than the template ; routers do not match these so do not capture empty values
+				break
+			}
 			value = ""
 		} else {
 			value = urlParts[i]
@@ -148,14 +275,27 @@ func (r Route) extractParameters(urlPath string) map[string]string {
 					pathParameters[keyPart] = value
 				}
 			} else {
-				// without enclosing {}
-				pathParameters[key[1:len(key)-1]] = value
+				// without enclosing {} and optional marker
+				pathParameters[strings.TrimSuffix(key[1:len(key)-1], "?")] = value
 			}
 		}
 	}
 	return pathParameters
 }
 
+// extractEscapedWildcardParameter replaces the value of the wildcard path parameter (if any) by the remainder of the escaped path.
+func (r Route) extractEscapedWildcardParameter(escapedPath string, pathParameters map[string]string) {
+	for i, key := range r.pathParts {
+		if strings.HasPrefix(key, "{") && strings.HasSuffix(key, ":*}") {
+			urlParts := tokenizePath(escapedPath)
+			if i < len(urlParts) {
+				pathParameters[key[1:len(key)-3]] = untokenizePath(i, urlParts)
+			}
+			return
+		}
+	}
+}
+
 // Untokenize back into an URL path using the slash separator
 func untokenizePath(offset int, parts []string) string {
 	var buffer bytes.Buffer
@@ -171,13 +311,87 @@ func untokenizePath(offset int, parts []string) string {
 
 // Tokenize an URL path using the slash separator ; the result does not have empty tokens
 func tokenizePath(path string) []string {
+	return appendPathTokens(make([]string, 0, strings.Count(path, "/")+1), path)
+}
+
+// appendPathTokens appends the tokens of the path to tokens and returns the extended slice.
+// The tokens are the same as those of tokenizePath but no memory is allocated if tokens has enough capacity.
+func appendPathTokens(tokens []string, path string) []string {
 	if "/" == path {
-		return []string{}
+		return tokens
+	}
+	path = strings.Trim(path, "/")
+	for {
+		slash := strings.IndexByte(path, '/')
+		if slash == -1 {
+			return append(tokens, path)
+		}
+		tokens = append(tokens, path[:slash])
+		path = path[slash+1:]
+	}
+}
+
+// pathTokensPool holds reusable slices for tokenizing request paths while dispatching.
+var pathTokensPool = sync.Pool{New: func() interface{} {
+	tokens := make([]string, 0, 16)
+	return &tokens
+}}
+
+// borrowPathTokens returns the tokens of the path using a pooled slice.
+// The slice must be returned using releasePathTokens and must not be retained.
+func borrowPathTokens(path string) *[]string {
+	tokens := pathTokensPool.Get().(*[]string)
+	*tokens = appendPathTokens((*tokens)[:0], path)
+	return tokens
+}
+
+// releasePathTokens returns the slice of borrowPathTokens to the pool.
+func releasePathTokens(tokens *[]string) {
+	pathTokensPool.Put(tokens)
+}
+
+// duplicatePathParameterName returns the first name of a path parameter that is used more than once in the path, if any.
+func duplicatePathParameterName(path string) (string, bool) {
+	seen := map[string]bool{}
+	for _, each := range tokenizePath(path) {
+		if !strings.HasPrefix(each, "{") || !strings.HasSuffix(each, "}") {
+			continue
+		}
+		name := strings.TrimSuffix(each[1:len(each)-1], "?")
+		if colon := strings.Index(name, ":"); colon != -1 {
+			name = name[:colon]
+		}
+		if seen[name] {
+			return name, true
+		}
+		seen[name] = true
 	}
-	return strings.Split(strings.Trim(path, "/"), "/")
+	return "", false
+}
+
+// isOptionalParameterToken returns whether the path token is a parameter that may be absent, e.g. {category?}
+func isOptionalParameterToken(token string) bool {
+	return strings.HasPrefix(token, "{") && strings.HasSuffix(token, "?}")
 }
 
 // for debugging
 func (r Route) String() string {
 	return r.Method + " " + r.Path
 }
+
+// VerboseString returns the method and path followed by the media types the Route consumes and produces
+// and its operation, if these are set. E.g. "POST /users consumes:[application/json] produces:[application/json] operation:createUser".
+// Use it for diagnosing "415: Unsupported Media Type" and "406: Not Acceptable" responses.
+func (r Route) VerboseString() string {
+	s := r.String()
+	if len(r.Consumes) > 0 {
+		s += " consumes:[" + strings.Join(r.Consumes, ",") + "]"
+	}
+	if len(r.Produces) > 0 {
+		s += " produces:[" + strings.Join(r.Produces, ",") + "]"
+	}
+	if len(r.Operation) > 0 {
+		s += " operation:" + r.Operation
+	}
+	return s
+}
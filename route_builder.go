@@ -5,6 +5,7 @@ package restful
 // that can be found in the LICENSE file.
 
 import (
+	"errors"
 	"os"
 	"reflect"
 	"runtime"
@@ -20,12 +21,16 @@ type RouteBuilder struct {
 	produces    []string
 	consumes    []string
 	httpMethod  string        // required
+	alsoMethods []string      // additional methods for which the same Route is created
 	function    RouteFunction // required
 	filters     []FilterFunction
+	// if true then responses are not compressed
+	disableCompression bool
 	// documentation
 	doc                     string
 	notes                   string
 	operation               string
+	tags                    []string
 	readSample, writeSample interface{}
 	parameters              []*Parameter
 	errorMap                map[int]ResponseError
@@ -59,6 +64,15 @@ func (b *RouteBuilder) Method(method string) *RouteBuilder {
 	return b
 }
 
+// AlsoMethod adds a Http method for which a Route is created with the same path, function and documentation,
+// e.g. to handle both PUT and POST identically:
+//
+//	ws.Route(ws.PUT("/{id}").AlsoMethod("POST").To(saveItem))
+func (b *RouteBuilder) AlsoMethod(method string) *RouteBuilder {
+	b.alsoMethods = append(b.alsoMethods, method)
+	return b
+}
+
 // Produces specifies what MIME types can be produced ; the matched one will appear in the Content-Type Http header.
 func (b *RouteBuilder) Produces(mimeTypes ...string) *RouteBuilder {
 	b.produces = mimeTypes
@@ -89,6 +103,12 @@ func (b *RouteBuilder) Notes(notes string) *RouteBuilder {
 	return b
 }
 
+// Tags adds tags, such as the name of the resource, for grouping this route in generated documentation. Optional.
+func (b *RouteBuilder) Tags(tags ...string) *RouteBuilder {
+	b.tags = append(b.tags, tags...)
+	return b
+}
+
 // Reads tells what resource type will be read from the request payload. Optional.
 // A parameter of type "body" is added ,required is set to true and the dataType is set to the qualified name of the sample's type.
 func (b *RouteBuilder) Reads(sample interface{}) *RouteBuilder {
@@ -174,6 +194,13 @@ func (b *RouteBuilder) Filter(filter FilterFunction) *RouteBuilder {
 	return b
 }
 
+// DisableCompression tells that responses of this Route must not be compressed,
+// e.g. because the content is already compressed or streamed. Optional.
+func (b *RouteBuilder) DisableCompression() *RouteBuilder {
+	b.disableCompression = true
+	return b
+}
+
 // If no specific Route path then set to rootPath
 // If no specific Produces then set to rootProduces
 // If no specific Consumes then set to rootConsumes
@@ -188,14 +215,26 @@ func (b *RouteBuilder) copyDefaults(rootProduces, rootConsumes []string) {
 
 // Build creates a new Route using the specification details collected by the RouteBuilder
 func (b *RouteBuilder) Build() Route {
-	pathExpr, err := newPathExpression(b.currentPath)
+	route, err := b.BuildE()
 	if err != nil {
-		log.Printf("[restful] Invalid path:%s because:%v", b.currentPath, err)
+		log.Printf("[restful] %v", err)
 		os.Exit(1)
 	}
+	return route
+}
+
+// BuildE is like Build but returns an error, instead of exiting the process, if the path is invalid
+// or no function is specified. The error is a *PathError if the path cannot be compiled.
+func (b *RouteBuilder) BuildE() (Route, error) {
+	pathExpr, err := newPathExpression(b.currentPath)
+	if err != nil {
+		return Route{}, &PathError{Path: b.currentPath, Err: err}
+	}
 	if b.function == nil {
-		log.Printf("[restful] No function specified for route:" + b.currentPath)
-		os.Exit(1)
+		return Route{}, errors.New("no function specified for route:" + b.currentPath)
+	}
+	if name, ok := duplicatePathParameterName(concatPath(b.rootPath, b.currentPath)); ok {
+		log.Printf("[restful] Duplicate path parameter:%s in route:%s", name, concatPath(b.rootPath, b.currentPath))
 	}
 	operationName := b.operation
 	if len(operationName) == 0 && b.function != nil {
@@ -203,23 +242,25 @@ func (b *RouteBuilder) Build() Route {
 		operationName = nameOfFunction(b.function)
 	}
 	route := Route{
-		Method:         b.httpMethod,
-		Path:           concatPath(b.rootPath, b.currentPath),
-		Produces:       b.produces,
-		Consumes:       b.consumes,
-		Function:       b.function,
-		Filters:        b.filters,
-		relativePath:   b.currentPath,
-		pathExpr:       pathExpr,
-		Doc:            b.doc,
-		Notes:          b.notes,
-		Operation:      operationName,
-		ParameterDocs:  b.parameters,
-		ResponseErrors: b.errorMap,
-		ReadSample:     b.readSample,
-		WriteSample:    b.writeSample}
+		Method:             b.httpMethod,
+		Path:               concatPath(b.rootPath, b.currentPath),
+		Produces:           b.produces,
+		Consumes:           b.consumes,
+		Function:           b.function,
+		Filters:            b.filters,
+		DisableCompression: b.disableCompression,
+		relativePath:       b.currentPath,
+		pathExpr:           pathExpr,
+		Doc:                b.doc,
+		Notes:              b.notes,
+		Operation:          operationName,
+		Tags:               b.tags,
+		ParameterDocs:      b.parameters,
+		ResponseErrors:     b.errorMap,
+		ReadSample:         b.readSample,
+		WriteSample:        b.writeSample}
 	route.postBuild()
-	return route
+	return route, nil
 }
 
 func concatPath(path1, path2 string) string {
@@ -1,6 +1,7 @@
 package restful
 
 import (
+	"strings"
 	"testing"
 )
 
@@ -56,3 +57,27 @@ func TestRouteBuilder(t *testing.T) {
 		t.Error("Operation not set")
 	}
 }
+
+func TestRouteBuilderTags(t *testing.T) {
+	r := new(RouteBuilder).To(dummy).Path("/users").Method("GET").Tags("users").Tags("admin").Build()
+	if got, want := strings.Join(r.Tags, ","), "users,admin"; got != want {
+		t.Errorf("got %v want %v", got, want)
+	}
+}
+
+func TestRouteBuilderBuildE(t *testing.T) {
+	_, err := new(RouteBuilder).Path("/users/{id:[}").To(doNothing).BuildE()
+	if _, ok := err.(*PathError); !ok {
+		t.Errorf("got %v want *PathError", err)
+	}
+	if _, err := new(RouteBuilder).Path("/users").BuildE(); err == nil {
+		t.Error("expected error for missing function")
+	}
+	route, err := new(RouteBuilder).Method("GET").Path("/users/{id}").To(doNothing).BuildE()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := route.Path, "/users/{id}"; got != want {
+		t.Errorf("got %v want %v", got, want)
+	}
+}
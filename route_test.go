@@ -1,9 +1,20 @@
 package restful
 
 import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
 	"testing"
 )
 
+func TestRouteMatchesMissingAccept(t *testing.T) {
+	r := Route{Produces: []string{"text/csv"}}
+	if !r.matchesAccept("") {
+		t.Error("missing Accept should match any producible type")
+	}
+}
+
 // accept should match produces
 func TestMatchesAcceptPlainTextWhenProducePlainTextAsLast(t *testing.T) {
 	r := Route{Produces: []string{"application/json", "text/plain"}}
@@ -69,6 +80,44 @@ func TestMatchesContentTypeCharsetInformation(t *testing.T) {
 	}
 }
 
+// media types are case-insensitive
+func TestMatchesAcceptAndContentTypeMixedCase(t *testing.T) {
+	r := Route{Produces: []string{"application/json"}, Consumes: []string{"application/xml"}}
+	if !r.matchesAccept(" Application/JSON;q=0.9") {
+		t.Errorf("accept should match mixed case json")
+	}
+	if !r.matchesContentType("APPLICATION/Xml; charset=UTF-8") {
+		t.Errorf("content type should match mixed case xml")
+	}
+	if r.matchesContentType("Application/Json") {
+		t.Errorf("content type should not match json")
+	}
+}
+
+// media types are case-insensitive for writer negotiation too
+func TestDispatchAcceptMixedCase(t *testing.T) {
+	ws := new(WebService).Path("/mixed")
+	ws.Route(ws.GET("").Produces(MIME_JSON).To(func(req *Request, resp *Response) {
+		resp.WriteEntity(map[string]string{"case": "ignored"})
+	}))
+	c := NewContainer()
+	c.Add(ws)
+
+	httpRequest, _ := http.NewRequest("GET", "/mixed", nil)
+	httpRequest.Header.Set("Accept", "Application/JSON")
+	httpWriter := httptest.NewRecorder()
+	c.dispatch(httpWriter, httpRequest)
+	if got, want := httpWriter.Code, http.StatusOK; got != want {
+		t.Fatalf("got %v want %v", got, want)
+	}
+	if got, want := httpWriter.Header().Get(HEADER_ContentType), MIME_JSON; got != want {
+		t.Errorf("got %q want %q", got, want)
+	}
+	if got, want := strings.Join(strings.Fields(httpWriter.Body.String()), ""), `{"case":"ignored"}`; got != want {
+		t.Errorf("got %q want %q", got, want)
+	}
+}
+
 func TestMatchesPath_OneParam(t *testing.T) {
 	params := doExtractParams("/from/{source}", 2, "/from/here", t)
 	if params["source"] != "here" {
@@ -117,6 +166,152 @@ func TestTokenizePath(t *testing.T) {
 	}
 }
 
+// go test -v -test.run TestAppendPathTokens ...restful
+func TestAppendPathTokens(t *testing.T) {
+	// the tokenization before appendPathTokens was introduced
+	splitPath := func(path string) []string {
+		if "/" == path {
+			return []string{}
+		}
+		return strings.Split(strings.Trim(path, "/"), "/")
+	}
+	for _, each := range []string{"", "/", "//", "a", "/a", "a/", "/a/b/", "//a//b//", "/a//b", "/{x}/{y:*}", "/a/b/c/d/e/f/g/h/i/j/k/l/m/n/o/p/q"} {
+		want := splitPath(each)
+		for _, got := range [][]string{tokenizePath(each), appendPathTokens([]string{"x"}, each)[1:], *borrowPathTokens(each)} {
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("[%q] got %q want %q", each, got, want)
+			}
+		}
+	}
+}
+
+func BenchmarkTokenizePath(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		tokenizePath("/users/42/orders/7/lines")
+	}
+}
+
+func BenchmarkBorrowPathTokens(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		releasePathTokens(borrowPathTokens("/users/42/orders/7/lines"))
+	}
+}
+
+func TestRouteMatches(t *testing.T) {
+	ws := new(WebService).Path("/users")
+	ws.Route(ws.GET("/{user-id:[0-9]+}/orders/{order-id}").To(dummy))
+	route := ws.Routes()[0]
+	for _, each := range []struct {
+		method, path string
+		matches      bool
+	}{
+		{"GET", "/users/42/orders/7", true},
+		{"GET", "/users/42/orders/7/", true},
+		{"PUT", "/users/42/orders/7", false},
+		{"GET", "/users/john/orders/7", false},
+		{"GET", "/users/42/orders", false},
+		{"GET", "/users/42/orders/7/lines", false},
+		{"GET", "/customers/42/orders/7", false},
+	} {
+		if got, want := route.Matches(each.method, each.path), each.matches; got != want {
+			t.Errorf("[%s %s] got %v want %v", each.method, each.path, got, want)
+		}
+	}
+}
+
+func TestFewerSegmentsThanTemplateIsNoMatch(t *testing.T) {
+	for _, router := range []RouteSelector{RouterJSR311{}, CurlyRouter{}} {
+		ws := new(WebService).Path("/users")
+		ws.Route(ws.GET("/{id}").To(doNothing))
+		ws.Route(ws.GET("/{id}/orders/{page?}").To(doNothing))
+		c := NewContainer()
+		c.Router(router)
+		c.Add(ws)
+		for path, code := range map[string]int{
+			"/users":           http.StatusNotFound,
+			"/users/":          http.StatusNotFound,
+			"/users/42":        http.StatusOK,
+			"/users/42/orders": http.StatusOK,
+		} {
+			httpRequest, _ := http.NewRequest("GET", path, nil)
+			httpWriter := httptest.NewRecorder()
+			c.dispatch(httpWriter, httpRequest)
+			if got, want := httpWriter.Code, code; got != want {
+				t.Errorf("[%T] %s: got %v want %v", router, path, got, want)
+			}
+		}
+		if ws.Routes()[0].Matches("GET", "/users") {
+			t.Errorf("[%T] /users should not match /users/{id}", router)
+		}
+	}
+	// a custom RouteSelector may select it anyway ; then the parameter is absent, not empty
+	params := new(WebService).Path("/users").GET("/{id}").To(doNothing).Build().extractParameters("/users")
+	if _, ok := params["id"]; ok {
+		t.Errorf("got %v want no id", params)
+	}
+}
+
+// go test -v -test.run TestPreserveEscapedWildcardPath ...restful
+func TestPreserveEscapedWildcardPath(t *testing.T) {
+	defer SetPreserveEscapedWildcardPath(false)
+	ws := new(WebService).Path("/proxy")
+	ws.Route(ws.GET("/{service}/{rest:*}").To(dummy))
+	route := ws.Routes()[0]
+	for preserve, want := range map[bool]string{false: "a/b/c", true: "a%2Fb/c"} {
+		SetPreserveEscapedWildcardPath(preserve)
+		httpRequest, _ := http.NewRequest("GET", "http://here.com/proxy/files/a%2Fb/c", nil)
+		req, _ := route.wrapRequestResponse(httptest.NewRecorder(), httpRequest)
+		if got := req.PathParameter("rest"); got != want {
+			t.Errorf("[%v] got %v want %v", preserve, got, want)
+		}
+		if got, want := req.PathParameter("service"), "files"; got != want {
+			t.Errorf("[%v] got %v want %v", preserve, got, want)
+		}
+	}
+}
+
+func TestBuildPath(t *testing.T) {
+	for _, each := range []struct {
+		path   string
+		params map[string]string
+		want   string
+	}{
+		{"/users/{id}", map[string]string{"id": "42"}, "/users/42"},
+		{"/users/{id:[0-9]+}/orders/{order}", map[string]string{"id": "42", "order": "a b"}, "/users/42/orders/a%20b"},
+		{"/files/{rest:*}", map[string]string{"rest": "docs/read me.txt"}, "/files/docs/read%20me.txt"},
+		{"/books/{page?}", map[string]string{}, "/books"},
+		{"/", map[string]string{}, "/"},
+	} {
+		got, err := BuildPath(Route{Path: each.path}, each.params)
+		if err != nil {
+			t.Errorf("[%s] unexpected error %v", each.path, err)
+		}
+		if got != each.want {
+			t.Errorf("[%s] got %v want %v", each.path, got, each.want)
+		}
+	}
+	if _, err := BuildPath(Route{Path: "/users/{id}/orders/{order}"}, map[string]string{"id": "42"}); err == nil {
+		t.Error("expected error for missing order")
+	}
+}
+
+func TestDuplicatePathParameterName(t *testing.T) {
+	for path, want := range map[string]string{
+		"/users/{id}/posts/{id}":          "id",
+		"/users/{id:[0-9]+}/posts/{id?}":  "id",
+		"/users/{user}/posts/{post}":      "",
+		"/users/{user}/posts/{rest:*}":    "",
+		"/users/{user}/friends/{user:.*}": "user",
+	} {
+		got, _ := duplicatePathParameterName(path)
+		if got != want {
+			t.Errorf("[%s] got %q want %q", path, got, want)
+		}
+	}
+}
+
 func doExtractParams(routePath string, size int, urlPath string, t *testing.T) map[string]string {
 	r := Route{Path: routePath}
 	r.postBuild()
@@ -125,3 +320,53 @@ func doExtractParams(routePath string, size int, urlPath string, t *testing.T) m
 	}
 	return r.extractParameters(urlPath)
 }
+
+func TestOptionalLastPathParameter(t *testing.T) {
+	for _, router := range []RouteSelector{RouterJSR311{}, CurlyRouter{}} {
+		ws := new(WebService).Path("/shop")
+		ws.Route(ws.GET("/items/{category?}").To(dummy))
+		for path, want := range map[string]string{"/shop/items": "", "/shop/items/books": "books"} {
+			req, _ := http.NewRequest("GET", path, nil)
+			_, route, err := router.SelectRoute([]*WebService{ws}, req)
+			if err != nil {
+				t.Fatalf("[%T] %s unexpected error %v", router, path, err)
+			}
+			params := route.extractParameters(path)
+			if got, ok := params["category"]; !ok || got != want {
+				t.Errorf("[%T] %s got %q want %q", router, path, got, want)
+			}
+		}
+	}
+}
+
+func TestRouteVerboseString(t *testing.T) {
+	ws := new(WebService).Path("/users").Consumes(MIME_JSON).Produces(MIME_JSON, MIME_XML)
+	ws.Route(ws.POST("/").Operation("createUser").To(doNothing))
+	if got, want := ws.Routes()[0].VerboseString(), "POST /users/ consumes:[application/json] produces:[application/json,application/xml] operation:createUser"; got != want {
+		t.Errorf("got %v want %v", got, want)
+	}
+	if got, want := (Route{Method: "GET", Path: "/ping"}).VerboseString(), "GET /ping"; got != want {
+		t.Errorf("got %v want %v", got, want)
+	}
+}
+
+func TestRouteEffectiveProducesAndConsumes(t *testing.T) {
+	ws := new(WebService).Path("/food").Produces(MIME_JSON, MIME_XML).Consumes(MIME_JSON)
+	ws.Route(ws.GET("/inherit").To(doNothing))
+	ws.Route(ws.POST("/override").Produces("text/csv").Consumes("text/csv", MIME_XML).To(doNothing))
+	for _, each := range []struct {
+		route              Route
+		produces, consumes string
+	}{
+		{ws.Routes()[0], "application/json,application/xml", "application/json"},
+		{ws.Routes()[1], "text/csv", "text/csv,application/xml"},
+		{(Route{Method: "GET", Path: "/none"}), "", "*/*"},
+	} {
+		if got, want := strings.Join(each.route.EffectiveProduces(), ","), each.produces; got != want {
+			t.Errorf("[%s] got %v want %v", each.route, got, want)
+		}
+		if got, want := strings.Join(each.route.EffectiveConsumes(), ","), each.consumes; got != want {
+			t.Errorf("[%s] got %v want %v", each.route, got, want)
+		}
+	}
+}
@@ -0,0 +1,60 @@
+package restful
+
+// Copyright 2026 agent. All rights reserved.
+// Use of this source code is governed by a license
+// that can be found in the LICENSE file.
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// NewServerTimingFilter returns a filter that adds a Server-Timing metric, with the name, to the response.
+// Its duration, in milliseconds, is the time the rest of the chain took until the response headers were written,
+// or until it returned if nothing was written. Install it at several positions, with different names,
+// to report the duration of each stage ; browsers show these metrics in their developer tools.
+func NewServerTimingFilter(name string) FilterFunction {
+	return func(req *Request, resp *Response, chain *FilterChain) {
+		timing := &serverTimingResponseWriter{ResponseWriter: resp.ResponseWriter, name: name, start: time.Now()}
+		resp.ResponseWriter = timing
+		chain.ProcessFilter(req, resp)
+		resp.ResponseWriter = timing.ResponseWriter
+		timing.addMetric()
+	}
+}
+
+// serverTimingResponseWriter is a http.ResponseWriter that adds a Server-Timing metric just before the headers are written.
+type serverTimingResponseWriter struct {
+	http.ResponseWriter
+	name  string
+	start time.Time
+	added bool
+}
+
+// addMetric adds the metric, with the time elapsed since start, unless it was added before.
+func (s *serverTimingResponseWriter) addMetric() {
+	if s.added {
+		return
+	}
+	s.added = true
+	elapsed := float64(time.Since(s.start)) / float64(time.Millisecond)
+	s.ResponseWriter.Header().Add(HEADER_ServerTiming, fmt.Sprintf("%s;dur=%.3f", s.name, elapsed))
+}
+
+// WriteHeader is part of http.ResponseWriter interface
+func (s *serverTimingResponseWriter) WriteHeader(status int) {
+	s.addMetric()
+	s.ResponseWriter.WriteHeader(status)
+}
+
+// Write is part of http.ResponseWriter interface
+func (s *serverTimingResponseWriter) Write(data []byte) (int, error) {
+	s.addMetric()
+	return s.ResponseWriter.Write(data)
+}
+
+// Unwrap returns the original http.ResponseWriter, e.g. for use by http.ResponseController.
+func (s *serverTimingResponseWriter) Unwrap() http.ResponseWriter {
+	return s.ResponseWriter
+}
@@ -0,0 +1,36 @@
+package restful
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+	"time"
+)
+
+func TestServerTimingFilter(t *testing.T) {
+	ws := new(WebService).Path("/timed")
+	ws.Filter(NewServerTimingFilter("total"))
+	ws.Route(ws.GET("/write").Filter(NewServerTimingFilter("handler")).To(func(req *Request, resp *Response) {
+		time.Sleep(2 * time.Millisecond)
+		io.WriteString(resp, "done")
+	}))
+	ws.Route(ws.GET("/empty").Filter(NewServerTimingFilter("handler")).To(doNothing))
+	c := NewContainer().Add(ws)
+	metric := regexp.MustCompile(`^(total|handler);dur=[0-9]+\.[0-9]{3}$`)
+	for _, path := range []string{"/timed/write", "/timed/empty"} {
+		httpRequest, _ := http.NewRequest("GET", path, nil)
+		httpWriter := httptest.NewRecorder()
+		c.dispatch(httpWriter, httpRequest)
+		metrics := httpWriter.Header()[HEADER_ServerTiming]
+		if got, want := len(metrics), 2; got != want {
+			t.Fatalf("%s: got %v want %v metrics: %v", path, got, want, metrics)
+		}
+		for _, each := range metrics {
+			if !metric.MatchString(each) {
+				t.Errorf("%s: unexpected metric %q", path, each)
+			}
+		}
+	}
+}
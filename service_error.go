@@ -4,12 +4,19 @@ package restful
 // Use of this source code is governed by a license
 // that can be found in the LICENSE file.
 
-import "fmt"
+import (
+	"fmt"
+	"strings"
+	"sync"
+)
 
 // ServiceError is a transport object to pass information about a non-Http error occurred in a WebService while processing a request.
+// Written as an entity (e.g. using Response.WriteServiceError), its JSON form is {"code":404,"message":"...","details":["..."]}
+// and its XML form is <ServiceError><code>404</code><message>...</message><details><detail>...</detail></details></ServiceError>.
 type ServiceError struct {
-	Code    int
-	Message string
+	Code    int      `json:"code" xml:"code"`
+	Message string   `json:"message" xml:"message"`
+	Details []string `json:"details,omitempty" xml:"details>detail,omitempty"`
 }
 
 // NewError returns a ServiceError using the code and reason
@@ -17,7 +24,109 @@ func NewError(code int, message string) ServiceError {
 	return ServiceError{Code: code, Message: message}
 }
 
+// WithDetails returns a copy of the ServiceError with the details, e.g. the fields that failed validation, appended.
+func (s ServiceError) WithDetails(details ...string) ServiceError {
+	s.Details = append(append([]string{}, s.Details...), details...)
+	return s
+}
+
 // Error returns a text representation of the service error
 func (s ServiceError) Error() string {
 	return fmt.Sprintf("[ServiceError:%v] %v", s.Code, s.Message)
 }
+
+// errorMessageCatalog is a singleton that associates a language to localized error messages by code.
+var errorMessageCatalog = &errorMessages{
+	protection:      new(sync.RWMutex),
+	messages:        map[string]map[int]string{},
+	defaultLanguage: "en",
+}
+
+type errorMessages struct {
+	protection      *sync.RWMutex
+	messages        map[string]map[int]string
+	defaultLanguage string
+}
+
+// RegisterErrorMessages adds/overrides the localized messages, by error code, for a language (e.g. "en","nl","pt-BR").
+// These messages are used to write the ServiceErrors of responses generated by the package,
+// in the language that best matches the Accept-Language header of the request.
+func RegisterErrorMessages(language string, messages map[int]string) {
+	errorMessageCatalog.protection.Lock()
+	defer errorMessageCatalog.protection.Unlock()
+	errorMessageCatalog.messages[strings.ToLower(language)] = messages
+}
+
+// DefaultErrorMessageLanguage sets the language of the registered error messages that is used
+// if none matches the Accept-Language header of the request. Default is "en".
+func DefaultErrorMessageLanguage(language string) {
+	errorMessageCatalog.protection.Lock()
+	defer errorMessageCatalog.protection.Unlock()
+	errorMessageCatalog.defaultLanguage = strings.ToLower(language)
+}
+
+// MessageFor returns the registered message for the code in the best matching language of acceptLanguage.
+// Current implementation ignores any q-parameters in the Accept-Language Header.
+func (e *errorMessages) MessageFor(code int, acceptLanguage string) (string, bool) {
+	e.protection.RLock()
+	defer e.protection.RUnlock()
+	if len(e.messages) == 0 {
+		return "", false
+	}
+	for _, qualifiedLanguage := range strings.Split(acceptLanguage, ",") {
+		language := strings.ToLower(strings.Trim(strings.Split(qualifiedLanguage, ";")[0], " "))
+		if len(language) == 0 {
+			continue
+		}
+		if msg, ok := e.messages[language][code]; ok {
+			return msg, true
+		}
+		// retry without region, e.g. nl-BE -> nl
+		if dash := strings.Index(language, "-"); dash != -1 {
+			if msg, ok := e.messages[language[:dash]][code]; ok {
+				return msg, true
+			}
+		}
+	}
+	msg, ok := e.messages[e.defaultLanguage][code]
+	return msg, ok
+}
+
+// ErrorMapper returns the Http status and the entity to write for an error, e.g. a domain specific error
+// returned by a RouteFunction. It returns a status of 0 if it does not map the error.
+type ErrorMapper func(err error) (int, interface{})
+
+// errorMapperRegistry is a singleton that holds the registered ErrorMappers in registration order.
+var errorMapperRegistry = &errorMappers{protection: new(sync.RWMutex)}
+
+type errorMappers struct {
+	protection *sync.RWMutex
+	mappers    []ErrorMapper
+}
+
+// RegisterErrorMapper adds a function that maps errors to a Http status and entity for Response.WriteErrorAuto.
+// Mappers are consulted in the order of registration ; the first that returns a non-zero status is used.
+func RegisterErrorMapper(mapper ErrorMapper) {
+	errorMapperRegistry.protection.Lock()
+	defer errorMapperRegistry.protection.Unlock()
+	errorMapperRegistry.mappers = append(errorMapperRegistry.mappers, mapper)
+}
+
+// resetErrorMappers removes all registered ErrorMappers, for testing.
+func resetErrorMappers() {
+	errorMapperRegistry.protection.Lock()
+	defer errorMapperRegistry.protection.Unlock()
+	errorMapperRegistry.mappers = nil
+}
+
+// Map returns the status and entity of the first registered mapper that maps the error.
+func (e *errorMappers) Map(err error) (int, interface{}, bool) {
+	e.protection.RLock()
+	defer e.protection.RUnlock()
+	for _, each := range e.mappers {
+		if status, entity := each(err); status != 0 {
+			return status, entity, true
+		}
+	}
+	return 0, nil, false
+}
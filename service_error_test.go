@@ -0,0 +1,120 @@
+package restful
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestLocalizedErrorMessages(t *testing.T) {
+	tearDown()
+	RegisterErrorMessages("en", map[int]string{http.StatusNotFound: "Not Found"})
+	RegisterErrorMessages("nl", map[int]string{http.StatusNotFound: "Niet gevonden"})
+	defer func() { errorMessageCatalog.messages = map[string]map[int]string{} }()
+
+	for language, want := range map[string]string{
+		"nl":                 "Niet gevonden",
+		"nl-BE,nl;q=0.9":     "Niet gevonden",
+		"en-US,en;q=0.8":     "Not Found",
+		"fr-FR, fr;q=0.9":    "Not Found",
+		"":                   "Not Found",
+		"de, nl-NL;q=0.5, *": "Niet gevonden",
+	} {
+		httpRequest, _ := http.NewRequest("GET", "http://here.com/missing", nil)
+		httpRequest.Header.Set("Accept-Language", language)
+		httpWriter := httptest.NewRecorder()
+		DefaultContainer.dispatch(httpWriter, httpRequest)
+		if got := httpWriter.Body.String(); got != want {
+			t.Errorf("[%s] got %q want %q", language, got, want)
+		}
+	}
+}
+
+func TestLocalizedNotAcceptable(t *testing.T) {
+	RegisterErrorMessages("nl", map[int]string{http.StatusNotAcceptable: "Niet acceptabel"})
+	defer func() { errorMessageCatalog.messages = map[string]map[int]string{} }()
+
+	httpRequest, _ := http.NewRequest("GET", "http://here.com/", nil)
+	httpRequest.Header.Set("Accept", "text/csv")
+	httpRequest.Header.Set("Accept-Language", "nl")
+	httpWriter := httptest.NewRecorder()
+	resp := NewResponse(httpWriter)
+	resp.requestAccept = httpRequest.Header.Get(HEADER_Accept)
+	resp.requestAcceptLanguage = httpRequest.Header.Get(HEADER_AcceptLanguage)
+	resp.WriteEntity("done")
+	if got, want := httpWriter.Code, http.StatusNotAcceptable; got != want {
+		t.Errorf("got %v want %v", got, want)
+	}
+	if got, want := httpWriter.Body.String(), "Niet acceptabel"; got != want {
+		t.Errorf("got %q want %q", got, want)
+	}
+}
+
+func TestUnregisteredErrorMessage(t *testing.T) {
+	if _, ok := errorMessageCatalog.MessageFor(http.StatusNotFound, "en"); ok {
+		t.Error("no message expected")
+	}
+}
+
+func TestServiceErrorEntityShapes(t *testing.T) {
+	serviceError := NewError(http.StatusBadRequest, "invalid order").WithDetails("quantity must be positive")
+	for mime, want := range map[string]string{
+		MIME_JSON: `{"code":400,"message":"invalid order","details":["quantity must be positive"]}`,
+		MIME_XML:  `<ServiceError><code>400</code><message>invalid order</message><details><detail>quantity must be positive</detail></details></ServiceError>`,
+	} {
+		httpWriter := httptest.NewRecorder()
+		resp := &Response{ResponseWriter: httpWriter, requestAccept: mime, routeProduces: []string{MIME_JSON, MIME_XML}}
+		resp.PrettyPrint(false)
+		resp.WriteServiceError(http.StatusBadRequest, serviceError)
+		if got := strings.TrimSpace(strings.TrimPrefix(httpWriter.Body.String(), xml.Header)); got != want {
+			t.Errorf("[%s] got %v want %v", mime, got, want)
+		}
+	}
+}
+
+type notFoundError struct {
+	resource string
+}
+
+func (n notFoundError) Error() string { return n.resource + " not found" }
+
+func TestWriteErrorAuto(t *testing.T) {
+	RegisterErrorMapper(func(err error) (int, interface{}) {
+		if notFound, ok := err.(notFoundError); ok {
+			return http.StatusNotFound, NewError(http.StatusNotFound, notFound.Error())
+		}
+		return 0, nil
+	})
+	defer resetErrorMappers()
+
+	for _, each := range []struct {
+		err  error
+		code int
+		body string
+	}{
+		{notFoundError{"order 42"}, http.StatusNotFound, `{"code":404,"message":"order 42 not found"}`},
+		{NewError(http.StatusConflict, "already exists"), http.StatusConflict, `{"code":409,"message":"already exists"}`},
+		{fmt.Errorf("create order: %w", NewError(http.StatusConflict, "already exists")), http.StatusConflict, `{"code":409,"message":"already exists"}`},
+		{errors.New("disk full"), http.StatusInternalServerError, "disk full"},
+		{nil, http.StatusInternalServerError, "500: Internal Server Error"},
+	} {
+		httpWriter := httptest.NewRecorder()
+		resp := &Response{ResponseWriter: httpWriter, requestAccept: MIME_JSON, routeProduces: []string{MIME_JSON}}
+		resp.PrettyPrint(false)
+		resp.WriteErrorAuto(each.err)
+		if got, want := httpWriter.Code, each.code; got != want {
+			t.Errorf("[%v] got %v want %v", each.err, got, want)
+		}
+		if got, want := strings.TrimSpace(httpWriter.Body.String()), each.body; got != want {
+			t.Errorf("[%v] got %v want %v", each.err, got, want)
+		}
+		if got := resp.Error(); each.err != nil && !reflect.DeepEqual(got, each.err) {
+			t.Errorf("[%v] got %v want the error kept", each.err, got)
+		}
+	}
+}
@@ -0,0 +1,52 @@
+package restful
+
+// Copyright 2026 agent. All rights reserved.
+// Use of this source code is governed by a license
+// that can be found in the LICENSE file.
+
+import (
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// NewStrictQueryFilter returns a filter that rejects requests with query parameters that are not documented
+// for the selected Route (see RouteBuilder.Param) and not listed as allowed, e.g. "pretty".
+// Http status BadRequest (400) is written with a message that lists the unexpected parameters, sorted by name.
+func NewStrictQueryFilter(allowed ...string) FilterFunction {
+	return func(req *Request, resp *Response, chain *FilterChain) {
+		if req.selectedRoute != nil {
+			unexpected := []string{}
+			for name := range req.Request.URL.Query() {
+				if !isDeclaredQueryParameter(req.selectedRoute, name) && !containsString(allowed, name) {
+					unexpected = append(unexpected, name)
+				}
+			}
+			if len(unexpected) > 0 {
+				sort.Strings(unexpected)
+				resp.WriteErrorString(http.StatusBadRequest, "400: Unexpected query parameters: "+strings.Join(unexpected, ","))
+				return
+			}
+		}
+		chain.ProcessFilter(req, resp)
+	}
+}
+
+// isDeclaredQueryParameter returns whether the Route documents a query parameter by that name.
+func isDeclaredQueryParameter(route *Route, name string) bool {
+	for _, each := range route.ParameterDocs {
+		if each.Kind() == QueryParameterKind && each.Name() == name {
+			return true
+		}
+	}
+	return false
+}
+
+func containsString(list []string, value string) bool {
+	for _, each := range list {
+		if each == value {
+			return true
+		}
+	}
+	return false
+}
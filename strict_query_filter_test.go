@@ -0,0 +1,39 @@
+package restful
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestStrictQueryFilter(t *testing.T) {
+	c := NewContainer()
+	ws := new(WebService).Path("/items")
+	ws.Filter(NewStrictQueryFilter("pretty"))
+	ws.Route(ws.GET("").
+		Param(ws.QueryParameter("limit", "maximum number of items").DataType("integer")).
+		Param(ws.HeaderParameter("offset", "not a query parameter")).
+		To(doNothing))
+	c.Add(ws)
+	for _, each := range []struct {
+		query string
+		code  int
+		body  string
+	}{
+		{"limit=10", http.StatusOK, ""},
+		{"limit=10&pretty", http.StatusOK, ""},
+		{"", http.StatusOK, ""},
+		{"limt=10", http.StatusBadRequest, "400: Unexpected query parameters: limt"},
+		{"offset=2&limit=1&debug=true", http.StatusBadRequest, "400: Unexpected query parameters: debug,offset"},
+	} {
+		httpRequest, _ := http.NewRequest("GET", "/items?"+each.query, nil)
+		httpWriter := httptest.NewRecorder()
+		c.dispatch(httpWriter, httpRequest)
+		if got, want := httpWriter.Code, each.code; got != want {
+			t.Errorf("[%s] got %v want %v", each.query, got, want)
+		}
+		if got, want := httpWriter.Body.String(), each.body; got != want {
+			t.Errorf("[%s] got %v want %v", each.query, got, want)
+		}
+	}
+}
@@ -0,0 +1,51 @@
+package restful
+
+// Copyright 2026 agent. All rights reserved.
+// Use of this source code is governed by a license
+// that can be found in the LICENSE file.
+
+import (
+	"net/http"
+	"path"
+	"strings"
+)
+
+// suffixMIMETypes maps the known extensions of a request path to the MIME type of the response.
+var suffixMIMETypes = map[string]string{
+	".json": MIME_JSON,
+	".xml":  MIME_XML,
+}
+
+// selectRouteBySuffix selects a Route, of the WebServices with suffix negotiation, for the request path
+// without its known extension. It returns a copy of the request, without the extension and with its Accept header
+// replaced by the MIME type of the extension, to dispatch instead. It returns false if no such WebService has a Route
+// for that path or if detected, the WebService selected for the unchanged request path, is another WebService.
+// webServicesLock must be held.
+func (c *Container) selectRouteBySuffix(httpRequest *http.Request, detected *WebService) (*http.Request, *WebService, *Route, bool, error) {
+	mime, ok := suffixMIMETypes[path.Ext(httpRequest.URL.Path)]
+	if !ok {
+		return nil, nil, nil, false, nil
+	}
+	candidates := []*WebService{}
+	for _, each := range c.webServices {
+		if each.suffixNegotiation {
+			candidates = append(candidates, each)
+		}
+	}
+	if len(candidates) == 0 {
+		return nil, nil, nil, false, nil
+	}
+	stripped := httpRequest.Clone(httpRequest.Context())
+	stripped.URL.Path = strings.TrimSuffix(stripped.URL.Path, path.Ext(stripped.URL.Path))
+	stripped.URL.RawPath = ""
+	stripped.Header.Set(HEADER_Accept, mime)
+	webService, route, err := c.router.SelectRoute(candidates, stripped)
+	if serviceErr, ok := err.(ServiceError); ok && serviceErr.Code == http.StatusNotFound {
+		return nil, nil, nil, false, nil
+	}
+	// a WebService that matches the request path better is not overruled
+	if detected != nil && detected != webService {
+		return nil, nil, nil, false, nil
+	}
+	return stripped, webService, route, true, err
+}
@@ -0,0 +1,87 @@
+package restful
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestSuffixNegotiation(t *testing.T) {
+	for _, router := range []RouteSelector{RouterJSR311{}, CurlyRouter{}} {
+		ws := new(WebService).Path("/data").SetSuffixNegotiation(true)
+		ws.Route(ws.GET("/{kind}").Produces(MIME_JSON, MIME_XML).To(func(req *Request, resp *Response) {
+			resp.WriteEntity(food{Kind: req.PathParameter("kind")})
+		}))
+		ws.Route(ws.GET("/{kind}/label").Produces(MIME_JSON).To(doNothing))
+		c := NewContainer()
+		c.Router(router)
+		c.Add(ws)
+		for _, each := range []struct {
+			path, accept string
+			code         int
+			contentType  string
+			body         string
+		}{
+			{"/data/apple.xml", MIME_JSON, http.StatusOK, MIME_XML, "<Kind>apple</Kind>"},
+			{"/data/apple.json", MIME_XML, http.StatusOK, MIME_JSON, `"Kind": "apple"`},
+			{"/data/apple", MIME_XML, http.StatusOK, MIME_XML, "<Kind>apple</Kind>"},
+			{"/data/apple/label.xml", "", http.StatusNotAcceptable, "", ""},
+			{"/data/apple.txt", MIME_JSON, http.StatusOK, MIME_JSON, `"Kind": "apple.txt"`},
+		} {
+			httpRequest, _ := http.NewRequest("GET", each.path, nil)
+			httpRequest.Header.Set(HEADER_Accept, each.accept)
+			httpWriter := httptest.NewRecorder()
+			c.dispatch(httpWriter, httpRequest)
+			if got, want := httpWriter.Code, each.code; got != want {
+				t.Errorf("[%T] %s: got %v want %v", router, each.path, got, want)
+			}
+			if got, want := httpWriter.Header().Get(HEADER_ContentType), each.contentType; len(want) > 0 && got != want {
+				t.Errorf("[%T] %s: got %v want %v", router, each.path, got, want)
+			}
+			if got, want := httpWriter.Body.String(), each.body; !strings.Contains(got, want) {
+				t.Errorf("[%T] %s: got %v want %v", router, each.path, got, want)
+			}
+		}
+	}
+}
+
+func TestSuffixNegotiationDisabled(t *testing.T) {
+	ws := new(WebService).Path("/data")
+	ws.Route(ws.GET("/{kind}").To(func(req *Request, resp *Response) {
+		resp.Write([]byte(req.PathParameter("kind")))
+	}))
+	httpRequest, _ := http.NewRequest("GET", "/data/apple.xml", nil)
+	httpWriter := httptest.NewRecorder()
+	NewContainer().Add(ws).dispatch(httpWriter, httpRequest)
+	if got, want := httpWriter.Body.String(), "apple.xml"; got != want {
+		t.Errorf("got %v want %v", got, want)
+	}
+}
+
+func TestSuffixNegotiationBestWebService(t *testing.T) {
+	for _, router := range []RouteSelector{RouterJSR311{}, CurlyRouter{}} {
+		any := new(WebService).Path("/").SetSuffixNegotiation(true)
+		any.Route(any.GET("/{path:*}").Produces(MIME_JSON).To(func(req *Request, resp *Response) {
+			resp.Write([]byte("any " + req.PathParameter("path")))
+		}))
+		files := new(WebService).Path("/files")
+		files.Route(files.GET("/report.json").To(func(req *Request, resp *Response) {
+			resp.Write([]byte("report"))
+		}))
+		c := NewContainer()
+		c.Router(router)
+		c.Add(any).Add(files)
+		for path, body := range map[string]string{
+			"/files/report.json": "report",
+			"/docs/intro.json":   "any docs/intro",
+		} {
+			httpRequest, _ := http.NewRequest("GET", path, nil)
+			httpWriter := httptest.NewRecorder()
+			c.dispatch(httpWriter, httpRequest)
+			if got, want := httpWriter.Body.String(), body; got != want {
+				t.Errorf("[%T] %s: got %v want %v", router, path, got, want)
+			}
+		}
+	}
+}
@@ -0,0 +1,31 @@
+package restful
+
+// Copyright 2026 agent. All rights reserved.
+// Use of this source code is governed by a license
+// that can be found in the LICENSE file.
+
+import "net/http"
+
+// VersionAttribute is the name of the Request attribute that holds the version resolved by a version gate filter.
+const VersionAttribute = "restful.version"
+
+// NewVersionGateFilter returns a filter that only passes requests for which the value of the header
+// is one of the supported versions, ordered from oldest to latest. A request without the header gets the latest version.
+// Other requests are rejected with Http status BadRequest (400).
+// The resolved version is stored as the Request attribute VersionAttribute.
+func NewVersionGateFilter(header string, supported []string) FilterFunction {
+	return func(req *Request, resp *Response, chain *FilterChain) {
+		version := req.HeaderParameter(header)
+		if len(version) == 0 && len(supported) > 0 {
+			version = supported[len(supported)-1]
+		}
+		for _, each := range supported {
+			if each == version {
+				req.SetAttribute(VersionAttribute, version)
+				chain.ProcessFilter(req, resp)
+				return
+			}
+		}
+		resp.WriteErrorString(http.StatusBadRequest, "400: Unsupported "+header+": "+version)
+	}
+}
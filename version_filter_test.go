@@ -0,0 +1,41 @@
+package restful
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestVersionGateFilter(t *testing.T) {
+	c := NewContainer()
+	ws := new(WebService).Path("/versioned")
+	ws.Filter(NewVersionGateFilter("X-API-Version", []string{"1", "2"}))
+	ws.Route(ws.GET("").To(func(req *Request, resp *Response) {
+		io.WriteString(resp, req.Attribute(VersionAttribute).(string))
+	}))
+	c.Add(ws)
+	for _, each := range []struct {
+		version string
+		code    int
+		body    string
+	}{
+		{"1", http.StatusOK, "1"},
+		{"2", http.StatusOK, "2"},
+		{"", http.StatusOK, "2"},
+		{"3", http.StatusBadRequest, "400: Unsupported X-API-Version: 3"},
+	} {
+		httpRequest, _ := http.NewRequest("GET", "/versioned", nil)
+		if len(each.version) > 0 {
+			httpRequest.Header.Set("X-API-Version", each.version)
+		}
+		httpWriter := httptest.NewRecorder()
+		c.dispatch(httpWriter, httpRequest)
+		if got, want := httpWriter.Code, each.code; got != want {
+			t.Errorf("[%s] got %v want %v", each.version, got, want)
+		}
+		if got, want := httpWriter.Body.String(), each.body; got != want {
+			t.Errorf("[%s] got %v want %v", each.version, got, want)
+		}
+	}
+}
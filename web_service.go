@@ -1,8 +1,13 @@
 package restful
 
 import (
+	"bytes"
+	"errors"
 	"fmt"
+	"io"
+	"net/http"
 	"os"
+	"strings"
 	"sync"
 
 	"github.com/emicklei/go-restful/log"
@@ -16,6 +21,7 @@ import (
 type WebService struct {
 	rootPath       string
 	pathExpr       *pathExpression // cached compilation of rootPath as RegExp
+	pathErr        *PathError      // set if rootPath could not be compiled
 	routes         []Route
 	produces       []string
 	consumes       []string
@@ -23,28 +29,292 @@ type WebService struct {
 	filters        []FilterFunction
 	documentation  string
 	apiVersion     string
+	tags           []string
+	externalDocs   ExternalDocumentation
 
-	dynamicRoutes bool
+	// if set then all error responses generated by the package use this MIME type
+	errorContentType string
+
+	// headers that are set on each response before the Route function is called
+	defaultResponseHeaders map[string]string
+
+	// accessors for this WebService only ; consulted before the global ones
+	accessors *entityReaderWriters
+
+	// MIME type assumed for request bodies without a Content-Type header
+	consumesDefault string
+
+	// MIME type set on responses of Routes that write content without a Content-Type header
+	defaultResponseContentType string
+
+	dynamicRoutes  bool
+	maxRoutes      int  // if > 0 then Routes beyond this number are not added
+	explainRouting bool // if true then the reasons why no Route matches a request are logged
+	// if true then a known extension (e.g. .json) of the request path selects the MIME type of the response
+	suffixNegotiation bool
+	requestObserver   RequestObserverFunction
+
+	// if set then these are called instead of writing the 404 or 405 ServiceError
+	notFoundHandler         RouteFunction
+	methodNotAllowedHandler RouteFunction
 
 	// protects 'routes' if dynamic routes are enabled
 	routesLock sync.RWMutex
+
+	// protects 'filters' and 'pathParameters' if dynamic routes are enabled
+	filtersLock sync.RWMutex
 }
 
 func (w *WebService) SetDynamicRoutes(enable bool) {
 	w.dynamicRoutes = enable
 }
 
+// ErrTooManyRoutes is reported if a Route is added to a WebService that already has the maximum number of Routes.
+var ErrTooManyRoutes = errors.New("maximum number of routes exceeded")
+
+// SetMaxRoutes sets the maximum number of Routes of this WebService, e.g. to prevent runaway registration
+// if dynamic routes are enabled. Adding a Route beyond this number is logged, with ErrTooManyRoutes, and ignored.
+// Default is 0 which means no maximum.
+func (w *WebService) SetMaxRoutes(n int) *WebService {
+	w.routesLock.Lock()
+	defer w.routesLock.Unlock()
+	w.maxRoutes = n
+	return w
+}
+
+// RequestObserverFunction declares functions that can be used to observe the dispatching of a request.
+// It is called with start=true before the request is passed to the filters and the Route function,
+// and with start=false after that. If no Route was selected then the route argument is the zero value.
+// When called with start=false, the payload sizes are available using req.BodyBytesRead() and resp.ContentLength().
+type RequestObserverFunction func(start bool, req *Request, resp *Response, route Route)
+
+// SetRequestObserver sets the function that observes each request dispatched to this WebService.
+// This can be used to integrate with tracing tools without installing a filter. Default is nil.
+func (w *WebService) SetRequestObserver(observer RequestObserverFunction) *WebService {
+	w.requestObserver = observer
+	return w
+}
+
+var doPanicOnInvalidPath = true
+
+// SetPanicOnInvalidPath controls whether WebService.Path panics, with a *PathError, if the root path is invalid.
+// If false then the error is logged and returned by WebService.Validate instead. Default is true.
+func SetPanicOnInvalidPath(panicOnError bool) {
+	doPanicOnInvalidPath = panicOnError
+}
+
+// PathError describes a path template that cannot be compiled.
+type PathError struct {
+	Path string
+	Err  error
+}
+
+func (p *PathError) Error() string {
+	return fmt.Sprintf("invalid path:%s because:%v", p.Path, p.Err)
+}
+
 // compilePathExpression ensures that the path is compiled into a RegEx for those routers that need it.
 func (w *WebService) compilePathExpression() {
+	if err := w.compilePathExpressionE(); err != nil {
+		if doPanicOnInvalidPath {
+			panic(err)
+		}
+		log.Printf("[restful] %v", err)
+	}
+}
+
+// compilePathExpressionE is like compilePathExpression but returns the *PathError instead.
+func (w *WebService) compilePathExpressionE() error {
 	if len(w.rootPath) == 0 {
-		w.Path("/") // lazy initialize path
+		w.rootPath = "/" // lazy initialize path
 	}
 	compiled, err := newPathExpression(w.rootPath)
 	if err != nil {
-		log.Printf("[restful] invalid path:%s because:%v", w.rootPath, err)
-		os.Exit(1)
+		w.pathErr = &PathError{Path: w.rootPath, Err: err}
+		w.pathExpr = nil
+		return w.pathErr
 	}
+	w.pathErr = nil
 	w.pathExpr = compiled
+	return nil
+}
+
+// Validate returns an error that describes all invalid paths of this WebService and its Routes, if any.
+// A path is also invalid if it uses the same path parameter name more than once.
+func (w *WebService) Validate() error {
+	problems := []string{}
+	if w.pathErr != nil {
+		problems = append(problems, w.pathErr.Error())
+	}
+	w.routesLock.RLock()
+	defer w.routesLock.RUnlock()
+	for _, each := range w.routes {
+		// the path of a Route under an invalid root path is invalid too ; the root error is reported once
+		if w.pathErr != nil && strings.HasPrefix(each.Path, w.rootPath) {
+			continue
+		}
+		if _, err := newPathExpression(each.Path); err != nil {
+			problems = append(problems, (&PathError{Path: each.Path, Err: err}).Error())
+		}
+		if name, ok := duplicatePathParameterName(each.Path); ok {
+			problems = append(problems, fmt.Sprintf("duplicate path parameter:%s in path:%s", name, each.Path))
+		}
+	}
+	if len(problems) == 0 {
+		return nil
+	}
+	return errors.New(strings.Join(problems, "; "))
+}
+
+// HandleRequest selects the Route of this WebService for the method and path and dispatches a request
+// with the body and headers to it, using a new Container, without a listener. It returns the recorded response.
+// It is meant for testing RouteFunctions and filters ; an error is returned if the request or the WebService is invalid.
+//
+//	rec, err := ws.HandleRequest("GET", "/users/42", nil, http.Header{"Accept": {restful.MIME_JSON}})
+func (w *WebService) HandleRequest(method, path string, body io.Reader, headers http.Header) (*ResponseRecorder, error) {
+	if err := w.Validate(); err != nil {
+		return nil, err
+	}
+	httpRequest, err := http.NewRequest(method, path, body)
+	if err != nil {
+		return nil, err
+	}
+	for name, values := range headers {
+		httpRequest.Header[name] = values
+	}
+	recorder := &ResponseRecorder{Code: http.StatusOK, HeaderMap: http.Header{}, Body: new(bytes.Buffer)}
+	w.Handler().ServeHTTP(recorder, httpRequest)
+	return recorder, nil
+}
+
+// ResponseRecorder is a http.ResponseWriter that keeps the status, headers and content of a response in memory.
+// It is returned by WebService.HandleRequest.
+type ResponseRecorder struct {
+	Code        int
+	HeaderMap   http.Header
+	Body        *bytes.Buffer
+	wroteHeader bool
+}
+
+// Header is part of http.ResponseWriter interface
+func (r *ResponseRecorder) Header() http.Header {
+	return r.HeaderMap
+}
+
+// WriteHeader is part of http.ResponseWriter interface ; only the first status is recorded.
+func (r *ResponseRecorder) WriteHeader(status int) {
+	if r.wroteHeader {
+		return
+	}
+	r.Code = status
+	r.wroteHeader = true
+}
+
+// Write is part of http.ResponseWriter interface
+func (r *ResponseRecorder) Write(data []byte) (int, error) {
+	r.WriteHeader(http.StatusOK)
+	return r.Body.Write(data)
+}
+
+// Flush is part of http.Flusher interface ; the content is already in memory.
+func (r *ResponseRecorder) Flush() {
+	r.WriteHeader(http.StatusOK)
+}
+
+// Handler returns a http.Handler that selects the Route of this WebService and dispatches requests to it,
+// using a new Container. It can be registered on any http.ServeMux, e.g. mux.Handle("/", ws.Handler()).
+// Requests that do not match a Route get a 404, 405, 406 or 415 response as usual.
+func (w *WebService) Handler() http.Handler {
+	return http.HandlerFunc(NewContainer().Add(w).dispatch)
+}
+
+// SetErrorResponseContentType sets the MIME type used to write the ServiceError of responses
+// generated by the package such as 405, 406 and 415. Its EntityReaderWriter must be registered.
+// Default is empty which means the error message is written as plain text.
+func (w *WebService) SetErrorResponseContentType(mime string) *WebService {
+	w.errorContentType = mime
+	return w
+}
+
+// SetNotFoundHandler sets the function that is called, instead of writing a 404 response,
+// when the request path matches the root path of this WebService but none of its Routes.
+// Requests that do not match the root path of any WebService are not handled by it.
+func (w *WebService) SetNotFoundHandler(handler RouteFunction) *WebService {
+	w.notFoundHandler = handler
+	return w
+}
+
+// SetMethodNotAllowedHandler sets the function that is called, instead of writing a 405 response,
+// when the request path matches a Route of this WebService but the HTTP method does not.
+func (w *WebService) SetMethodNotAllowedHandler(handler RouteFunction) *WebService {
+	w.methodNotAllowedHandler = handler
+	return w
+}
+
+// missHandler returns the function, if set, that handles a failed Route selection with this status code.
+func (w *WebService) missHandler(code int) RouteFunction {
+	switch code {
+	case http.StatusNotFound:
+		return w.notFoundHandler
+	case http.StatusMethodNotAllowed:
+		return w.methodNotAllowedHandler
+	}
+	return nil
+}
+
+// SetDefaultResponseContentType sets the MIME type (e.g. application/json) that is used as the Content-Type
+// of a response, of a Route of this WebService, that is written without a Content-Type header, e.g. using Response.Write.
+// Without it, net/http detects the type from the content. Default is empty.
+func (w *WebService) SetDefaultResponseContentType(mime string) *WebService {
+	w.defaultResponseContentType = mime
+	return w
+}
+
+// SetSuffixNegotiation controls whether a known extension of the request path, e.g. /report.xml, is used
+// to select the MIME type of the response instead of the Accept header. The extension is stripped before
+// selecting the Route such that a Route with path /report handles it, and the Accept header of the request is replaced
+// by the MIME type of the extension. Known extensions are .json and .xml. The extension is only used if this WebService
+// is also the best match for the request path with the extension. Default is false.
+func (w *WebService) SetSuffixNegotiation(enable bool) *WebService {
+	w.suffixNegotiation = enable
+	return w
+}
+
+// SetDefaultResponseHeaders sets the headers (e.g. X-Content-Type-Options) that are set on each
+// response of this WebService. These are set before the filters and the Route function are called
+// such that a function can override them. The headers are copied. Default is none.
+func (w *WebService) SetDefaultResponseHeaders(headers map[string]string) *WebService {
+	w.defaultResponseHeaders = make(map[string]string, len(headers))
+	for k, v := range headers {
+		w.defaultResponseHeaders[k] = v
+	}
+	return w
+}
+
+// SetConsumesDefault sets the MIME type (e.g. restful.MIME_JSON) that is assumed for a request
+// with content but without a Content-Type header. It is used for selecting the Route of this WebService
+// and by ReadEntity. Default is empty, which means application/octet-stream for selecting the Route.
+// Set it before adding the Routes.
+func (w *WebService) SetConsumesDefault(mime string) *WebService {
+	w.consumesDefault = mime
+	return w
+}
+
+// RegisterEntityAccessor add/overrides the ReaderWriter for encoding content with this MIME type,
+// for the Routes of this WebService only. It takes precedence over the one registered using the package function.
+func (w *WebService) RegisterEntityAccessor(mime string, erw EntityReaderWriter) *WebService {
+	if w.accessors == nil {
+		w.accessors = newEntityReaderWriters()
+	}
+	w.accessors.register(mime, erw)
+	return w
+}
+
+// setDefaultResponseHeaders sets all default response headers on the response.
+func (w *WebService) setDefaultResponseHeaders(resp *Response) {
+	for k, v := range w.defaultResponseHeaders {
+		resp.Header().Set(k, v)
+	}
 }
 
 // ApiVersion sets the API version for documentation purposes.
@@ -64,8 +334,34 @@ func (w *WebService) Path(root string) *WebService {
 	return w
 }
 
+// PathE is like Path but returns a *PathError if the root path is invalid, instead of panicking.
+func (w *WebService) PathE(root string) (*WebService, error) {
+	w.rootPath = root
+	return w, w.compilePathExpressionE()
+}
+
+// MountWebService prepends the prefix to the root path of the WebService and to the path of all its Routes.
+// Use this to compose WebServices, from separate modules, under a common path such as "/v2".
+// It must be called before the WebService is added to a Container.
+func MountWebService(prefix string, ws *WebService) *WebService {
+	ws.routesLock.Lock()
+	defer ws.routesLock.Unlock()
+	if len(ws.rootPath) == 0 || "/" == ws.rootPath {
+		ws.Path(prefix)
+	} else {
+		ws.Path(concatPath(prefix, ws.rootPath))
+	}
+	for ix := range ws.routes {
+		ws.routes[ix].Path = concatPath(prefix, ws.routes[ix].Path)
+		ws.routes[ix].postBuild()
+	}
+	return ws
+}
+
 // Param adds a PathParameter to document parameters used in the root path.
 func (w *WebService) Param(parameter *Parameter) *WebService {
+	w.filtersLock.Lock()
+	defer w.filtersLock.Unlock()
 	if w.pathParameters == nil {
 		w.pathParameters = []*Parameter{}
 	}
@@ -144,12 +440,66 @@ func FormParameter(name, description string) *Parameter {
 }
 
 // Route creates a new Route using the RouteBuilder and add to the ordered list of Routes.
+// If the RouteBuilder has additional methods (see RouteBuilder.AlsoMethod) then a Route is added for each method.
+// A HEAD Route that does not specify what it produces, produces the same as the GET Route with the same path.
 func (w *WebService) Route(builder *RouteBuilder) *WebService {
+	if err := w.RouteE(builder); err != nil {
+		if err != ErrTooManyRoutes {
+			log.Printf("[restful] %v", err)
+			os.Exit(1)
+		}
+		log.Printf("[restful] %v: route %s %s not added to WebService with root path %s",
+			err, builder.httpMethod, builder.currentPath, w.rootPath)
+	}
+	return w
+}
+
+// RouteE is like Route but returns an error, instead of exiting the process, if the Route cannot be built.
+// Use it when paths are not known at compile time, e.g. read from configuration.
+// ErrTooManyRoutes is returned if the maximum number of Routes is reached.
+// If the RouteBuilder has additional methods then either all or none of their Routes are added.
+func (w *WebService) RouteE(builder *RouteBuilder) error {
+	method := builder.httpMethod
+	defer func() { builder.httpMethod = method }()
+	explicitProduces := len(builder.produces) > 0
+	routes := []Route{}
+	for _, each := range append([]string{method}, builder.alsoMethods...) {
+		builder.httpMethod = each
+		builder.copyDefaults(w.produces, w.consumes)
+		route, err := builder.BuildE()
+		if err != nil {
+			return err
+		}
+		route.inheritsProduces = "HEAD" == each && !explicitProduces
+		route.consumesDefault = w.consumesDefault
+		routes = append(routes, route)
+	}
 	w.routesLock.Lock()
 	defer w.routesLock.Unlock()
-	builder.copyDefaults(w.produces, w.consumes)
-	w.routes = append(w.routes, builder.Build())
-	return w
+	if w.maxRoutes > 0 && len(w.routes)+len(routes) > w.maxRoutes {
+		return ErrTooManyRoutes
+	}
+	for _, each := range routes {
+		w.addRoute(each)
+	}
+	return nil
+}
+
+// addRoute adds the built Route ; routesLock must be held.
+func (w *WebService) addRoute(route Route) {
+	for ix := range w.routes {
+		if w.routes[ix].Path != route.Path {
+			continue
+		}
+		// mirror the content negotiation of GET for HEAD
+		if route.inheritsProduces && "GET" == w.routes[ix].Method {
+			route.Produces = w.routes[ix].Produces
+		}
+		if "GET" == route.Method && w.routes[ix].inheritsProduces {
+			w.routes[ix].Produces = route.Produces
+		}
+	}
+	w.routes = append(w.routes, route)
 }
 
 // RemoveRoute removes the specified route, looks for something that matches 'path' and 'method'
@@ -157,14 +507,29 @@ func (w *WebService) RemoveRoute(path, method string) error {
 	if !w.dynamicRoutes {
 		return fmt.Errorf("dynamic routes are not enabled.")
 	}
+	w.RemoveRouteMatching(func(each Route) bool {
+		return each.Method == method && each.Path == path
+	})
+	return nil
+}
+
+// RemoveRouteMatching removes all routes for which the predicate returns true and returns the number removed.
+// If dynamic routes are not enabled then no routes are removed.
+func (w *WebService) RemoveRouteMatching(predicate func(Route) bool) int {
+	if !w.dynamicRoutes {
+		return 0
+	}
 	w.routesLock.Lock()
 	defer w.routesLock.Unlock()
-	for ix := range w.routes {
-		if w.routes[ix].Method == method && w.routes[ix].Path == path {
-			w.routes = append(w.routes[:ix], w.routes[ix+1:]...)
+	kept := make([]Route, 0, len(w.routes))
+	for _, each := range w.routes {
+		if !predicate(each) {
+			kept = append(kept, each)
 		}
 	}
-	return nil
+	removed := len(w.routes) - len(kept)
+	w.routes = kept
+	return removed
 }
 
 // Method creates a new RouteBuilder and initialize its http method
@@ -201,22 +566,159 @@ func (w WebService) Routes() []Route {
 	return result
 }
 
+// AddExamplesRoute adds a GET Route, with the subPath, that responds with a JSON object that has an example
+// response for each Route with a WriteSample. Its keys are the method and path of a Route, e.g. "GET /users/{id}".
+// Routes added later are included too. Use it to help consumers and mock clients.
+func (w *WebService) AddExamplesRoute(subPath string) *WebService {
+	return w.Route(w.GET(subPath).
+		Doc("example responses of the routes of this service").
+		Operation("examples").
+		Produces(MIME_JSON).
+		To(func(req *Request, resp *Response) {
+			examples := map[string]interface{}{}
+			for _, each := range w.Routes() {
+				if each.WriteSample != nil {
+					examples[each.Method+" "+each.Path] = each.WriteSample
+				}
+			}
+			resp.WriteAsJson(examples)
+		}))
+}
+
+// AddStaticContent adds a GET Route, with the subPath followed by a {subpath:*} wildcard, that serves the files
+// of the directory using http.FileServer ; it sets the Content-Type by extension and honors If-Modified-Since.
+// Requests with a ".." segment in the path, that may refer to files outside the directory, are rejected
+// with Http Status BadRequest (400).
+func (w *WebService) AddStaticContent(subPath, directory string) *WebService {
+	files := http.FileServer(http.Dir(directory))
+	return w.Route(w.GET(strings.TrimRight(subPath, "/") + "/{subpath:*}").
+		Doc("static content of " + directory).
+		Operation("static").
+		To(func(req *Request, resp *Response) {
+			name := req.PathParameter("subpath")
+			for _, each := range strings.FieldsFunc(name, func(r rune) bool { return r == '/' || r == '\\' }) {
+				if each == ".." {
+					resp.WriteErrorString(http.StatusBadRequest, "400: Invalid path")
+					return
+				}
+			}
+			fileRequest := req.Request.Clone(req.Request.Context())
+			fileRequest.URL.Path = "/" + name
+			fileRequest.URL.RawPath = ""
+			files.ServeHTTP(resp, fileRequest)
+		}))
+}
+
+// PathPrefixMatches returns whether the path is the root path of this WebService or below it.
+// Path parameters in the root path match any value. Use it to decide whether to delegate a request to its Container.
+func (w *WebService) PathPrefixMatches(path string) bool {
+	if w.pathExpr == nil {
+		return false
+	}
+	return w.pathExpr.Matcher.MatchString(path)
+}
+
+// RouteByOperation returns the Route that has the Operation name (see RouteBuilder.Operation).
+// Returns false if no such Route exists.
+func (w *WebService) RouteByOperation(name string) (Route, bool) {
+	if w.dynamicRoutes {
+		w.routesLock.RLock()
+		defer w.routesLock.RUnlock()
+	}
+	for _, each := range w.routes {
+		if each.Operation == name {
+			return each, true
+		}
+	}
+	return Route{}, false
+}
+
+// routeListing is the JSON representation of a Route written by the route listing of AddRouteListing.
+type routeListing struct {
+	Method    string   `json:"method"`
+	Path      string   `json:"path"`
+	Doc       string   `json:"doc,omitempty"`
+	Operation string   `json:"operation,omitempty"`
+	Consumes  []string `json:"consumes,omitempty"`
+	Produces  []string `json:"produces,omitempty"`
+}
+
+// AddRouteListing adds a GET Route, with the subPath, that responds with a JSON array of all the Routes of this WebService.
+// Use it for debugging or to quickly inspect a running API.
+func (w *WebService) AddRouteListing(subPath string) *WebService {
+	return w.Route(w.GET(subPath).Produces(MIME_JSON).Doc("list all routes of this service").To(func(req *Request, resp *Response) {
+		if w.dynamicRoutes {
+			w.routesLock.RLock()
+			defer w.routesLock.RUnlock()
+		}
+		listing := make([]routeListing, len(w.routes))
+		for i, each := range w.routes {
+			listing[i] = routeListing{each.Method, each.Path, each.Doc, each.Operation, each.Consumes, each.Produces}
+		}
+		resp.WriteAsJson(listing)
+	}))
+}
+
 // RootPath returns the RootPath associated with this WebService. Default "/"
 func (w WebService) RootPath() string {
 	return w.rootPath
 }
 
 // PathParameters return the path parameter names for (shared amoung its Routes)
-func (w WebService) PathParameters() []*Parameter {
+func (w *WebService) PathParameters() []*Parameter {
+	if !w.dynamicRoutes {
+		return w.pathParameters
+	}
+	w.filtersLock.RLock()
+	defer w.filtersLock.RUnlock()
 	return w.pathParameters
 }
 
-// Filter adds a filter function to the chain of filters applicable to all its Routes
+// Filter adds a filter function to the chain of filters applicable to all its Routes.
+// If dynamic routes are enabled then this can be called while requests are dispatched.
 func (w *WebService) Filter(filter FilterFunction) *WebService {
+	w.filtersLock.Lock()
+	defer w.filtersLock.Unlock()
 	w.filters = append(w.filters, filter)
 	return w
 }
 
+// FilterForPrefix adds a filter function that is only applied to requests with a path that starts with the
+// prefix, relative to the root path of this WebService, e.g. "/admin". It matches whole path segments only,
+// so "/admin" does not apply to "/administrators". Other requests pass the filter as if it were absent.
+func (w *WebService) FilterForPrefix(prefix string, filter FilterFunction) *WebService {
+	return w.Filter(func(req *Request, resp *Response, chain *FilterChain) {
+		// computed per request because the root path may change by mounting
+		root := strings.TrimSuffix(w.RootPath(), "/") + "/" + strings.Trim(prefix, "/")
+		path := req.Request.URL.Path
+		if path == root || strings.HasPrefix(path, root+"/") {
+			filter(req, resp, chain)
+			return
+		}
+		chain.ProcessFilter(req, resp)
+	})
+}
+
+// Filters returns a copy of the filter functions applicable to all its Routes
+func (w *WebService) Filters() []FilterFunction {
+	w.filtersLock.RLock()
+	defer w.filtersLock.RUnlock()
+	result := make([]FilterFunction, len(w.filters))
+	copy(result, w.filters)
+	return result
+}
+
+// dispatchFilters returns the filter functions to pass a request through.
+// The result must not be modified.
+func (w *WebService) dispatchFilters() []FilterFunction {
+	if !w.dynamicRoutes {
+		return w.filters
+	}
+	w.filtersLock.RLock()
+	defer w.filtersLock.RUnlock()
+	return w.filters
+}
+
 // Doc is used to set the documentation of this service.
 func (w *WebService) Doc(plainText string) *WebService {
 	w.documentation = plainText
@@ -228,6 +730,34 @@ func (w WebService) Documentation() string {
 	return w.documentation
 }
 
+// Tags adds tags, such as the name of the resource, for grouping the Routes in generated documentation.
+func (w *WebService) Tags(tags ...string) *WebService {
+	w.tags = append(w.tags, tags...)
+	return w
+}
+
+// TagNames returns the tags for documentation purposes.
+func (w *WebService) TagNames() []string {
+	return w.tags
+}
+
+// ExternalDocumentation refers to documentation that is hosted elsewhere.
+type ExternalDocumentation struct {
+	Description string
+	URL         string
+}
+
+// ExternalDocs sets the reference to external documentation of this service, for documentation purposes.
+func (w *WebService) ExternalDocs(description, url string) *WebService {
+	w.externalDocs = ExternalDocumentation{Description: description, URL: url}
+	return w
+}
+
+// ExternalDocumentation returns the reference to external documentation. Its URL is empty if not set.
+func (w *WebService) ExternalDocumentation() ExternalDocumentation {
+	return w.externalDocs
+}
+
 /*
 	Convenience methods
 */
@@ -261,3 +791,9 @@ func (w *WebService) PATCH(subPath string) *RouteBuilder {
 func (w *WebService) DELETE(subPath string) *RouteBuilder {
 	return new(RouteBuilder).servicePath(w.rootPath).Method("DELETE").Path(subPath)
 }
+
+// On is a shortcut for .Method(strings.ToUpper(httpMethod)).Path(subPath) ; use it for methods
+// without a shortcut such as TRACE or the WebDAV PROPFIND.
+func (w *WebService) On(httpMethod, subPath string) *RouteBuilder {
+	return new(RouteBuilder).servicePath(w.rootPath).Method(strings.ToUpper(httpMethod)).Path(subPath)
+}
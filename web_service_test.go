@@ -1,8 +1,17 @@
 package restful
 
 import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
 	"testing"
 )
 
@@ -193,6 +202,726 @@ func TestContentTypeOctet_Issue170(t *testing.T) {
 	}
 }
 
+type observation struct {
+	start bool
+	path  string
+}
+
+func TestRequestObserver(t *testing.T) {
+	tearDown()
+	observed := []observation{}
+	ws := newSelectedRouteTestingService()
+	ws.SetRequestObserver(func(start bool, req *Request, resp *Response, route Route) {
+		observed = append(observed, observation{start, route.Path})
+	})
+	Add(ws)
+	for _, each := range []string{"http://here.com/get/1/friends", "http://here.com/get/1/enemies"} {
+		httpRequest, _ := http.NewRequest("GET", each, nil)
+		DefaultContainer.dispatch(httptest.NewRecorder(), httpRequest)
+	}
+	want := []observation{{true, pathGetFriends}, {false, pathGetFriends}, {true, ""}, {false, ""}}
+	if len(observed) != len(want) {
+		t.Fatalf("got %v want %v", observed, want)
+	}
+	for i := range want {
+		if observed[i] != want[i] {
+			t.Errorf("[%d] got %v want %v", i, observed[i], want[i])
+		}
+	}
+}
+
+func TestRequestObserverPayloadSizes(t *testing.T) {
+	c := NewContainer()
+	ws := new(WebService).Path("/sizes")
+	ws.Route(ws.POST("").Consumes(MIME_JSON).To(func(req *Request, resp *Response) {
+		sam := new(Sample)
+		req.ReadEntity(sam)
+		io.WriteString(resp, "hello")
+	}))
+	var requestSize, responseSize int
+	ws.SetRequestObserver(func(start bool, req *Request, resp *Response, route Route) {
+		if !start {
+			requestSize, responseSize = req.BodyBytesRead(), resp.ContentLength()
+		}
+	})
+	c.Add(ws)
+	httpRequest, _ := http.NewRequest("POST", "http://here.com/sizes", strings.NewReader(`{"Value":"42"}`))
+	httpRequest.Header.Set("Content-Type", MIME_JSON)
+	c.dispatch(httptest.NewRecorder(), httpRequest)
+	if got, want := requestSize, len(`{"Value":"42"}`); got != want {
+		t.Errorf("got %v want %v", got, want)
+	}
+	if got, want := responseSize, len("hello"); got != want {
+		t.Errorf("got %v want %v", got, want)
+	}
+
+	// requests without content keep http.NoBody
+	ws.Route(ws.GET("").To(func(req *Request, resp *Response) {
+		if req.Request.Body != http.NoBody {
+			t.Errorf("got %T want http.NoBody", req.Request.Body)
+		}
+	}))
+	httpRequest, _ = http.NewRequest("GET", "http://here.com/sizes", http.NoBody)
+	c.dispatch(httptest.NewRecorder(), httpRequest)
+	if got, want := requestSize, 0; got != want {
+		t.Errorf("got %v want %v", got, want)
+	}
+}
+
+func TestErrorResponseContentType(t *testing.T) {
+	tearDown()
+	ws := new(WebService).Path("/errors").SetErrorResponseContentType(MIME_JSON)
+	ws.Route(ws.GET("/get").To(doNothing))
+	ws.Route(ws.POST("/post").Consumes(MIME_JSON).Produces(MIME_JSON).To(doNothing))
+	ws.Route(ws.GET("/negotiate").Produces(MIME_JSON).To(func(req *Request, resp *Response) {
+		resp.SetRequestAccepts("text/csv")
+		resp.WriteEntity("done")
+	}))
+	Add(ws)
+	for _, each := range []struct {
+		method, path, contentType, accept string
+		code                              int
+	}{
+		{"DELETE", "/errors/get", "", "", http.StatusMethodNotAllowed},
+		{"POST", "/errors/post", "text/csv", "", http.StatusUnsupportedMediaType},
+		{"POST", "/errors/post", MIME_JSON, "text/csv", http.StatusNotAcceptable},
+		{"GET", "/errors/negotiate", "", "", http.StatusNotAcceptable},
+	} {
+		httpRequest, _ := http.NewRequest(each.method, "http://here.com"+each.path, nil)
+		if len(each.contentType) > 0 {
+			httpRequest.Header.Set("Content-Type", each.contentType)
+		}
+		if len(each.accept) > 0 {
+			httpRequest.Header.Set("Accept", each.accept)
+		}
+		httpWriter := httptest.NewRecorder()
+		DefaultContainer.dispatch(httpWriter, httpRequest)
+		if got, want := httpWriter.Code, each.code; got != want {
+			t.Errorf("[%s %s] got %v want %v", each.method, each.path, got, want)
+		}
+		if got, want := httpWriter.Header().Get("Content-Type"), MIME_JSON; got != want {
+			t.Errorf("[%s %s] got %v want %v", each.method, each.path, got, want)
+		}
+		if got, want := httpWriter.Body.String(), fmt.Sprintf(`"code": %d`, each.code); !strings.Contains(got, want) {
+			t.Errorf("[%s %s] got %v want %v", each.method, each.path, got, want)
+		}
+	}
+}
+
+func TestDefaultResponseHeaders(t *testing.T) {
+	tearDown()
+	headers := map[string]string{
+		"X-Content-Type-Options": "nosniff",
+		"Server":                 "restful"}
+	ws := new(WebService).Path("/secure").SetDefaultResponseHeaders(headers)
+	// later changes by the caller must not affect the WebService
+	headers["Server"] = "changed"
+	headers["X-Frame-Options"] = "DENY"
+	ws.Route(ws.GET("/default").To(doNothing))
+	ws.Route(ws.GET("/override").To(func(req *Request, resp *Response) {
+		resp.Header().Set("Server", "custom")
+	}))
+	Add(ws)
+	for path, server := range map[string]string{"/secure/default": "restful", "/secure/override": "custom"} {
+		httpRequest, _ := http.NewRequest("GET", "http://here.com"+path, nil)
+		httpWriter := httptest.NewRecorder()
+		DefaultContainer.dispatch(httpWriter, httpRequest)
+		if got, want := httpWriter.Header().Get("X-Content-Type-Options"), "nosniff"; got != want {
+			t.Errorf("[%s] got %v want %v", path, got, want)
+		}
+		if got, want := httpWriter.Header().Get("Server"), server; got != want {
+			t.Errorf("[%s] got %v want %v", path, got, want)
+		}
+		if got := httpWriter.Header().Get("X-Frame-Options"); got != "" {
+			t.Errorf("[%s] got %v want none", path, got)
+		}
+	}
+}
+
+// go test -race -v -test.run TestAddFilterWhileDispatching ...restful
+func TestAddFilterWhileDispatching(t *testing.T) {
+	c := NewContainer()
+	ws := new(WebService).Path("/dynamic")
+	ws.SetDynamicRoutes(true)
+	ws.Route(ws.GET("/get").To(doNothing))
+	c.Add(ws)
+	proceed := func(req *Request, resp *Response, chain *FilterChain) {
+		chain.ProcessFilter(req, resp)
+	}
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 100; i++ {
+			ws.Filter(proceed)
+			ws.Param(PathParameter("id", "identifier"))
+		}
+	}()
+	for i := 0; i < 100; i++ {
+		httpRequest, _ := http.NewRequest("GET", "http://here.com/dynamic/get", nil)
+		httpWriter := httptest.NewRecorder()
+		c.dispatch(httpWriter, httpRequest)
+		if got, want := httpWriter.Code, http.StatusOK; got != want {
+			t.Fatalf("got %v want %v", got, want)
+		}
+		ws.PathParameters()
+	}
+	wg.Wait()
+	if got, want := len(ws.Filters()), 100; got != want {
+		t.Errorf("got %v want %v", got, want)
+	}
+}
+
+func TestOnCustomMethod(t *testing.T) {
+	c := NewContainer()
+	ws := new(WebService).Path("/dav")
+	ws.Route(ws.On("propfind", "/{file}").To(func(req *Request, resp *Response) {
+		io.WriteString(resp, req.PathParameter("file"))
+	}))
+	c.Add(ws)
+	if got, want := ws.Routes()[0].Method, "PROPFIND"; got != want {
+		t.Errorf("got %v want %v", got, want)
+	}
+	httpRequest, _ := http.NewRequest("PROPFIND", "http://here.com/dav/notes.txt", nil)
+	httpWriter := httptest.NewRecorder()
+	c.dispatch(httpWriter, httpRequest)
+	if got, want := httpWriter.Body.String(), "notes.txt"; got != want {
+		t.Errorf("got %v want %v", got, want)
+	}
+}
+
+func TestRouteByOperation(t *testing.T) {
+	ws := new(WebService).Path("/users")
+	ws.Route(ws.GET("/{id}").Operation("findUser").To(doNothing))
+	ws.Route(ws.POST("").Operation("createUser").To(doNothing))
+	route, ok := ws.RouteByOperation("findUser")
+	if !ok {
+		t.Fatal("findUser not found")
+	}
+	if got, want := route.Path, "/users/{id}"; got != want {
+		t.Errorf("got %v want %v", got, want)
+	}
+	if _, ok := ws.RouteByOperation("deleteUser"); ok {
+		t.Error("deleteUser should not be found")
+	}
+}
+
+func TestAddRouteListing(t *testing.T) {
+	c := NewContainer()
+	ws := new(WebService).Path("/users")
+	ws.SetDynamicRoutes(true)
+	ws.Route(ws.GET("/{id}").Operation("findUser").Doc("get a user").To(doNothing))
+	ws.AddRouteListing("/routes")
+	ws.Route(ws.POST("").Consumes(MIME_JSON).To(doNothing))
+	c.Add(ws)
+	httpRequest, _ := http.NewRequest("GET", "http://here.com/users/routes", nil)
+	httpWriter := httptest.NewRecorder()
+	c.dispatch(httpWriter, httpRequest)
+	listing := []routeListing{}
+	if err := json.Unmarshal(httpWriter.Body.Bytes(), &listing); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := len(listing), 3; got != want {
+		t.Fatalf("got %v want %v", got, want)
+	}
+	if got, want := listing[0], (routeListing{Method: "GET", Path: "/users/{id}", Doc: "get a user", Operation: "findUser"}); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v want %v", got, want)
+	}
+	if got, want := listing[2], (routeListing{Method: "POST", Path: "/users/", Operation: "doNothing", Consumes: []string{MIME_JSON}}); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v want %v", got, want)
+	}
+}
+
+func TestPathE(t *testing.T) {
+	if _, err := new(WebService).PathE("/users/{id:[}"); err == nil {
+		t.Error("expected error for invalid path")
+	}
+	ws, err := new(WebService).PathE("/users")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := ws.RootPath(), "/users"; got != want {
+		t.Errorf("got %v want %v", got, want)
+	}
+}
+
+func TestPathPanicsOnInvalidPath(t *testing.T) {
+	defer func() {
+		err, ok := recover().(*PathError)
+		if !ok {
+			t.Fatalf("expected a *PathError, got %v", err)
+		}
+		if got, want := err.Path, "/users/{id:[}"; got != want {
+			t.Errorf("got %v want %v", got, want)
+		}
+	}()
+	new(WebService).Path("/users/{id:[}")
+}
+
+func TestValidateInvalidPath(t *testing.T) {
+	SetPanicOnInvalidPath(false)
+	defer SetPanicOnInvalidPath(true)
+	ws := new(WebService).Path("/users/{id:[}")
+	ws.Route(ws.GET("/orders").To(doNothing))
+	ws.Route(ws.GET("/invoices").To(doNothing))
+	err := ws.Validate()
+	if err == nil {
+		t.Fatal("expected error for invalid path")
+	}
+	if got, want := err.Error(), "invalid path:/users/{id:[} because:"; !strings.HasPrefix(got, want) {
+		t.Errorf("got %v want %v", got, want)
+	}
+	if got, want := strings.Count(err.Error(), "invalid path:"), 1; got != want {
+		t.Errorf("got %v want %v in %v", got, want, err)
+	}
+	if err := new(WebService).Path("/users").Validate(); err != nil {
+		t.Errorf("unexpected error %v", err)
+	}
+}
+
+func TestHeadMirrorsGetProduces(t *testing.T) {
+	writeFood := func(req *Request, resp *Response) { resp.WriteEntity(food{"Juicy"}) }
+	for _, headFirst := range []bool{false, true} {
+		c := NewContainer()
+		ws := new(WebService).Path("/food")
+		get := ws.GET("/{kind}").Produces(MIME_XML, MIME_JSON).To(writeFood)
+		head := ws.HEAD("/{kind}").To(writeFood)
+		if headFirst {
+			ws.Route(head).Route(get)
+		} else {
+			ws.Route(get).Route(head)
+		}
+		c.Add(ws)
+		for _, method := range []string{"GET", "HEAD"} {
+			httpRequest, _ := http.NewRequest(method, "http://here.com/food/apple", nil)
+			httpRequest.Header.Set("Accept", MIME_JSON)
+			httpWriter := httptest.NewRecorder()
+			c.dispatch(httpWriter, httpRequest)
+			if got, want := httpWriter.Code, http.StatusOK; got != want {
+				t.Errorf("[%s,%v] got %v want %v", method, headFirst, got, want)
+			}
+			if got, want := httpWriter.Header().Get("Content-Type"), MIME_JSON; got != want {
+				t.Errorf("[%s,%v] got %v want %v", method, headFirst, got, want)
+			}
+		}
+	}
+}
+
+func TestPathPrefixMatches(t *testing.T) {
+	ws := new(WebService).Path("/tenants/{tenant}/users")
+	for path, want := range map[string]bool{
+		"/tenants/acme/users":       true,
+		"/tenants/acme/users/":      true,
+		"/tenants/acme/users/42":    true,
+		"/tenants/acme/usersgroups": false,
+		"/tenants/acme":             false,
+		"/users":                    false,
+	} {
+		if got := ws.PathPrefixMatches(path); got != want {
+			t.Errorf("[%s] got %v want %v", path, got, want)
+		}
+	}
+	if !new(WebService).Path("/").PathPrefixMatches("/anything") {
+		t.Error("root path should match any path")
+	}
+}
+
+func TestValidateDuplicatePathParameter(t *testing.T) {
+	ws := new(WebService).Path("/users/{id}")
+	ws.Route(ws.GET("/posts/{id}").To(doNothing))
+	err := ws.Validate()
+	if err == nil {
+		t.Fatal("expected error for duplicate path parameter")
+	}
+	if got, want := err.Error(), "duplicate path parameter:id in path:/users/{id}/posts/{id}"; got != want {
+		t.Errorf("got %v want %v", got, want)
+	}
+}
+
+func TestDocumentationTagsAndExternalDocs(t *testing.T) {
+	ws := new(WebService).Path("/users").Doc("Manage users")
+	ws.Tags("users", "accounts").ExternalDocs("User guide", "https://example.com/users")
+	if got, want := ws.TagNames(), []string{"users", "accounts"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v want %v", got, want)
+	}
+	if got, want := ws.ExternalDocumentation(), (ExternalDocumentation{"User guide", "https://example.com/users"}); got != want {
+		t.Errorf("got %v want %v", got, want)
+	}
+}
+
+func TestSetConsumesDefault(t *testing.T) {
+	for _, consumesDefault := range []string{"", MIME_JSON} {
+		var kind string
+		c := NewContainer()
+		ws := new(WebService).Path("/food").Consumes(MIME_XML, MIME_JSON).SetConsumesDefault(consumesDefault)
+		ws.Route(ws.POST("").To(func(req *Request, resp *Response) {
+			var f food
+			if err := req.ReadEntity(&f); err != nil {
+				resp.WriteError(http.StatusBadRequest, err)
+				return
+			}
+			kind = f.Kind
+		}))
+		c.Add(ws)
+		httpRequest, _ := http.NewRequest("POST", "http://here.com/food", strings.NewReader(`{"Kind":"apple"}`))
+		httpWriter := httptest.NewRecorder()
+		c.dispatch(httpWriter, httpRequest)
+		want, wantKind := http.StatusUnsupportedMediaType, ""
+		if len(consumesDefault) > 0 {
+			want, wantKind = http.StatusOK, "apple"
+		}
+		if got := httpWriter.Code; got != want {
+			t.Errorf("[%s] got %v want %v", consumesDefault, got, want)
+		}
+		if kind != wantKind {
+			t.Errorf("[%s] got %q want %q", consumesDefault, kind, wantKind)
+		}
+	}
+}
+
+func TestHandleRequest(t *testing.T) {
+	ws := new(WebService).Path("/food").Consumes(MIME_JSON).Produces(MIME_JSON)
+	ws.Route(ws.GET("/{kind}").To(func(req *Request, resp *Response) {
+		resp.WriteEntity(food{Kind: req.PathParameter("kind")})
+	}))
+	ws.Route(ws.POST("").To(func(req *Request, resp *Response) {
+		var f food
+		if err := req.ReadEntity(&f); err != nil {
+			resp.WriteReadEntityError(err)
+			return
+		}
+		resp.WriteHeaderAndEntity(http.StatusCreated, f)
+	}))
+
+	rec, err := ws.HandleRequest("GET", "/food/apple", nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := rec.Code, http.StatusOK; got != want {
+		t.Errorf("got %v want %v", got, want)
+	}
+	if got, want := rec.Body.String(), `"Kind": "apple"`; !strings.Contains(got, want) {
+		t.Errorf("got %v want %v", got, want)
+	}
+
+	rec, err = ws.HandleRequest("POST", "/food", strings.NewReader(`{"Kind":"pear"}`), http.Header{"Content-Type": {MIME_JSON}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := rec.Code, http.StatusCreated; got != want {
+		t.Errorf("got %v want %v", got, want)
+	}
+	if got, want := rec.Header().Get(HEADER_ContentType), MIME_JSON; got != want {
+		t.Errorf("got %v want %v", got, want)
+	}
+	if got, want := rec.Body.String(), `"Kind": "pear"`; !strings.Contains(got, want) {
+		t.Errorf("got %v want %v", got, want)
+	}
+
+	if _, err := ws.HandleRequest("GET", "%zz", nil, nil); err == nil {
+		t.Error("expected error for invalid path")
+	}
+}
+
+func TestWebServiceHandler(t *testing.T) {
+	ws := new(WebService).Path("/food").Produces(MIME_JSON)
+	ws.Route(ws.GET("/{kind}").To(func(req *Request, resp *Response) {
+		resp.WriteEntity(food{Kind: req.PathParameter("kind")})
+	}))
+	mux := http.NewServeMux()
+	mux.Handle("/", ws.Handler())
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	for path, want := range map[string]int{"/food/apple": http.StatusOK, "/drinks": http.StatusNotFound} {
+		httpResponse, err := http.Get(server.URL + path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		body, _ := ioutil.ReadAll(httpResponse.Body)
+		httpResponse.Body.Close()
+		if got := httpResponse.StatusCode; got != want {
+			t.Errorf("[%s] got %v want %v", path, got, want)
+		}
+		if want == http.StatusOK && !strings.Contains(string(body), `"Kind": "apple"`) {
+			t.Errorf("[%s] unexpected body %s", path, body)
+		}
+	}
+}
+
+func TestFilterForPrefix(t *testing.T) {
+	c := NewContainer()
+	ws := new(WebService).Path("/shop")
+	ws.FilterForPrefix("/admin", func(req *Request, resp *Response, chain *FilterChain) {
+		resp.WriteErrorString(http.StatusForbidden, "403: Forbidden")
+	})
+	ws.Route(ws.GET("/admin").To(doNothing))
+	ws.Route(ws.GET("/admin/users").To(doNothing))
+	ws.Route(ws.GET("/administrators").To(doNothing))
+	ws.Route(ws.GET("/items").To(doNothing))
+	c.Add(ws)
+	for path, want := range map[string]int{
+		"/shop/admin":          http.StatusForbidden,
+		"/shop/admin/users":    http.StatusForbidden,
+		"/shop/administrators": http.StatusOK,
+		"/shop/items":          http.StatusOK,
+	} {
+		httpRequest, _ := http.NewRequest("GET", path, nil)
+		httpWriter := httptest.NewRecorder()
+		c.dispatch(httpWriter, httpRequest)
+		if got := httpWriter.Code; got != want {
+			t.Errorf("[%s] got %v want %v", path, got, want)
+		}
+	}
+}
+
+func TestSetMaxRoutes(t *testing.T) {
+	ws := new(WebService).Path("/tenants")
+	ws.SetDynamicRoutes(true)
+	ws.SetMaxRoutes(2)
+	ws.Route(ws.GET("/a").To(doNothing))
+	ws.Route(ws.GET("/b").To(doNothing))
+	if got, want := len(ws.Routes()), 2; got != want {
+		t.Errorf("got %v want %v", got, want)
+	}
+	ws.Route(ws.GET("/c").To(doNothing))
+	if got, want := len(ws.Routes()), 2; got != want {
+		t.Errorf("got %v want %v routes beyond maximum", got, want)
+	}
+	ws.SetMaxRoutes(0)
+	ws.Route(ws.GET("/c").To(doNothing))
+	if got, want := ws.Routes()[2].Path, "/tenants/c"; got != want {
+		t.Errorf("got %v want %v", got, want)
+	}
+}
+
+func TestRouteE(t *testing.T) {
+	ws := new(WebService).Path("/tenants")
+	if err := ws.RouteE(ws.GET("/{id:[}").To(doNothing)); err == nil {
+		t.Error("expected error for invalid path")
+	}
+	if got, want := len(ws.Routes()), 0; got != want {
+		t.Errorf("got %v want %v routes", got, want)
+	}
+	ws.SetMaxRoutes(1)
+	if err := ws.RouteE(ws.GET("/{id}").To(doNothing)); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := ws.RouteE(ws.GET("/other").To(doNothing)), ErrTooManyRoutes; got != want {
+		t.Errorf("got %v want %v", got, want)
+	}
+	// all or none of the Routes for additional methods are added
+	ws.SetMaxRoutes(2)
+	if got, want := ws.RouteE(ws.PUT("/{id}").AlsoMethod("POST").To(doNothing)), ErrTooManyRoutes; got != want {
+		t.Errorf("got %v want %v", got, want)
+	}
+	if got, want := len(ws.Routes()), 1; got != want {
+		t.Errorf("got %v want %v routes", got, want)
+	}
+}
+
+func TestRouteAlsoMethod(t *testing.T) {
+	c := NewContainer()
+	ws := new(WebService).Path("/items")
+	ws.Route(ws.PUT("/{id}").AlsoMethod("POST").To(func(req *Request, resp *Response) {
+		io.WriteString(resp, req.Request.Method+" "+req.PathParameter("id"))
+	}))
+	c.Add(ws)
+	if got, want := len(ws.Routes()), 2; got != want {
+		t.Fatalf("got %v want %v routes", got, want)
+	}
+	for _, method := range []string{"PUT", "POST", "GET"} {
+		httpRequest, _ := http.NewRequest(method, "/items/7", nil)
+		httpWriter := httptest.NewRecorder()
+		c.dispatch(httpWriter, httpRequest)
+		if method == "GET" {
+			if got, want := httpWriter.Code, http.StatusMethodNotAllowed; got != want {
+				t.Errorf("[%s] got %v want %v", method, got, want)
+			}
+			continue
+		}
+		if got, want := httpWriter.Body.String(), method+" 7"; got != want {
+			t.Errorf("[%s] got %v want %v", method, got, want)
+		}
+	}
+}
+
+func TestRemoveRouteMatching(t *testing.T) {
+	ws := new(WebService).Path("/tenants")
+	ws.SetDynamicRoutes(true)
+	ws.Route(ws.GET("/a").To(doNothing))
+	ws.Route(ws.GET("/b").To(doNothing))
+	ws.Route(ws.POST("/b").To(doNothing))
+	ws.Route(ws.GET("/c").To(doNothing))
+	if got, want := ws.RemoveRouteMatching(func(r Route) bool { return r.Method == "GET" && r.Path != "/tenants/c" }), 2; got != want {
+		t.Errorf("got %v want %v removed", got, want)
+	}
+	paths := []string{}
+	for _, each := range ws.Routes() {
+		paths = append(paths, each.String())
+	}
+	if got, want := strings.Join(paths, ","), "POST /tenants/b,GET /tenants/c"; got != want {
+		t.Errorf("got %v want %v", got, want)
+	}
+	// first of two routes ; used to index beyond the shrunk slice
+	if err := ws.RemoveRoute("/tenants/b", "POST"); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := len(ws.Routes()), 1; got != want {
+		t.Errorf("got %v want %v", got, want)
+	}
+	if got := new(WebService).RemoveRouteMatching(func(Route) bool { return true }); got != 0 {
+		t.Errorf("got %v removed without dynamic routes", got)
+	}
+}
+
+func TestMountWebService(t *testing.T) {
+	for _, router := range []RouteSelector{RouterJSR311{}, CurlyRouter{}} {
+		users := new(WebService).Path("/users")
+		users.Route(users.GET("/{user-id}").To(func(req *Request, resp *Response) {
+			io.WriteString(resp, req.PathParameter("user-id"))
+		}))
+		c := NewContainer()
+		c.Router(router)
+		c.Add(MountWebService("/v2", users))
+		if got, want := users.RootPath(), "/v2/users"; got != want {
+			t.Errorf("got %v want %v", got, want)
+		}
+		httpRequest, _ := http.NewRequest("GET", "http://here.com/v2/users/42", nil)
+		httpWriter := httptest.NewRecorder()
+		c.ServeHTTP(httpWriter, httpRequest)
+		if got, want := httpWriter.Body.String(), "42"; got != want {
+			t.Errorf("[%T] got %q want %q", router, got, want)
+		}
+	}
+}
+
+func TestSetNotFoundAndMethodNotAllowedHandler(t *testing.T) {
+	ws := new(WebService).Path("/app")
+	ws.Route(ws.GET("/items").To(doNothing))
+	ws.SetNotFoundHandler(func(req *Request, resp *Response) {
+		resp.WriteHeader(http.StatusOK)
+		io.WriteString(resp, "index")
+	})
+	ws.SetMethodNotAllowedHandler(func(req *Request, resp *Response) {
+		resp.WriteErrorString(http.StatusMethodNotAllowed, "only GET")
+	})
+	c := NewContainer().Add(ws)
+	for _, each := range []struct {
+		method, path string
+		code         int
+		body         string
+	}{
+		{"GET", "/app/unknown", http.StatusOK, "index"},
+		{"DELETE", "/app/items", http.StatusMethodNotAllowed, "only GET"},
+		{"GET", "/other", http.StatusNotFound, ""},
+	} {
+		httpRequest, _ := http.NewRequest(each.method, "http://here.com"+each.path, nil)
+		httpWriter := httptest.NewRecorder()
+		c.dispatch(httpWriter, httpRequest)
+		if got, want := httpWriter.Code, each.code; got != want {
+			t.Errorf("%s %s: got %v want %v", each.method, each.path, got, want)
+		}
+		if got, want := strings.TrimSpace(httpWriter.Body.String()), each.body; got != want {
+			t.Errorf("%s %s: got %q want %q", each.method, each.path, got, want)
+		}
+	}
+}
+
+func TestAddExamplesRoute(t *testing.T) {
+	ws := new(WebService).Path("/foods")
+	ws.AddExamplesRoute("/examples")
+	ws.Route(ws.GET("/{kind}").To(doNothing).Writes(food{Kind: "apple"}))
+	ws.Route(ws.DELETE("/{kind}").To(doNothing))
+	httpRequest, _ := http.NewRequest("GET", "/foods/examples", nil)
+	httpWriter := httptest.NewRecorder()
+	NewContainer().Add(ws).dispatch(httpWriter, httpRequest)
+	if got, want := httpWriter.Header().Get(HEADER_ContentType), MIME_JSON; got != want {
+		t.Errorf("got %v want %v", got, want)
+	}
+	examples := map[string]food{}
+	if err := json.Unmarshal(httpWriter.Body.Bytes(), &examples); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := examples, map[string]food{"GET /foods/{kind}": {Kind: "apple"}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v want %v", got, want)
+	}
+}
+
+func TestSetDefaultResponseContentType(t *testing.T) {
+	ws := new(WebService).Path("/raw").SetDefaultResponseContentType(MIME_JSON)
+	ws.Route(ws.GET("/write").To(func(req *Request, resp *Response) {
+		resp.Write([]byte(`{"raw":true}`))
+	}))
+	ws.Route(ws.GET("/created").To(func(req *Request, resp *Response) {
+		resp.WriteHeader(http.StatusCreated)
+		resp.Write([]byte(`{}`))
+	}))
+	ws.Route(ws.GET("/text").To(func(req *Request, resp *Response) {
+		resp.Header().Set(HEADER_ContentType, "text/plain")
+		resp.Write([]byte("plain"))
+	}))
+	ws.Route(ws.GET("/empty").To(func(req *Request, resp *Response) {
+		resp.WriteNoContent()
+	}))
+	c := NewContainer().Add(ws)
+	for path, want := range map[string]string{
+		"/raw/write":   MIME_JSON,
+		"/raw/created": MIME_JSON,
+		"/raw/text":    "text/plain",
+		"/raw/empty":   "",
+	} {
+		httpRequest, _ := http.NewRequest("GET", path, nil)
+		httpWriter := httptest.NewRecorder()
+		c.dispatch(httpWriter, httpRequest)
+		if got := httpWriter.Header().Get(HEADER_ContentType); got != want {
+			t.Errorf("%s: got %q want %q", path, got, want)
+		}
+	}
+}
+
+func TestAddStaticContent(t *testing.T) {
+	root := t.TempDir()
+	os.MkdirAll(filepath.Join(root, "public", "css"), 0755)
+	ioutil.WriteFile(filepath.Join(root, "public", "css", "site.css"), []byte("body {}"), 0644)
+	ioutil.WriteFile(filepath.Join(root, "secret.txt"), []byte("secret"), 0644)
+	ws := new(WebService).Path("/app")
+	ws.AddStaticContent("/assets", filepath.Join(root, "public"))
+	c := NewContainer().Add(ws)
+
+	httpRequest, _ := http.NewRequest("GET", "/app/assets/css/site.css", nil)
+	httpWriter := httptest.NewRecorder()
+	c.dispatch(httpWriter, httpRequest)
+	if got, want := httpWriter.Body.String(), "body {}"; got != want {
+		t.Errorf("got %q want %q", got, want)
+	}
+	if got, want := httpWriter.Header().Get(HEADER_ContentType), "text/css; charset=utf-8"; got != want {
+		t.Errorf("got %v want %v", got, want)
+	}
+	lastModified := httpWriter.Header().Get("Last-Modified")
+	httpRequest, _ = http.NewRequest("GET", "/app/assets/css/site.css", nil)
+	httpRequest.Header.Set("If-Modified-Since", lastModified)
+	httpWriter = httptest.NewRecorder()
+	c.dispatch(httpWriter, httpRequest)
+	if got, want := httpWriter.Code, http.StatusNotModified; got != want {
+		t.Errorf("got %v want %v", got, want)
+	}
+	for _, each := range []string{"/app/assets/../secret.txt", "/app/assets/css/%2e%2e/%2e%2e/secret.txt"} {
+		httpRequest, _ = http.NewRequest("GET", each, nil)
+		httpWriter = httptest.NewRecorder()
+		c.dispatch(httpWriter, httpRequest)
+		if strings.Contains(httpWriter.Body.String(), "secret") {
+			t.Errorf("%s: got %q outside the directory", each, httpWriter.Body.String())
+		}
+	}
+	httpRequest, _ = http.NewRequest("GET", "/app/assets/css/../../secret.txt", nil)
+	httpWriter = httptest.NewRecorder()
+	c.dispatch(httpWriter, httpRequest)
+	if got, want := httpWriter.Code, http.StatusBadRequest; got != want {
+		t.Errorf("got %v want %v", got, want)
+	}
+}
+
 func newPanicingService() *WebService {
 	ws := new(WebService).Path("")
 	ws.Route(ws.GET("/fire").To(doPanic))